@@ -16,6 +16,7 @@ import (
 	"hyperkaehler/internal/config"
 	"hyperkaehler/internal/db"
 	"hyperkaehler/internal/execution"
+	"hyperkaehler/internal/hedging"
 	"hyperkaehler/internal/market"
 	"hyperkaehler/internal/performance"
 	"hyperkaehler/internal/risk"
@@ -24,11 +25,21 @@ import (
 )
 
 func main() {
+	// `hyperkaehler migrate <up|down|status|create>` is a distinct subcommand
+	// from the flag-based modes below, so it's dispatched before flag.Parse
+	// sees it.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCLI(os.Args[2:])
+		return
+	}
+
 	// Parse CLI flags.
 	backtestMode := flag.Bool("backtest", false, "Run in backtest mode against historical data")
 	backtestFrom := flag.String("from", "", "Backtest start date (YYYY-MM-DD)")
 	backtestTo := flag.String("to", "", "Backtest end date (YYYY-MM-DD)")
 	backtestBalance := flag.Float64("balance", 2300, "Starting balance for backtest simulation")
+	fixFrom := flag.String("fix-from", "", "Rebuild positions/PnL from Manifold bet history since this date (YYYY-MM-DD), then exit")
+	dumpStats := flag.Bool("dump-stats", false, "Recompute and print each strategy's rolling TradeStats snapshot, then exit")
 	flag.Parse()
 
 	// Set up structured logging.
@@ -64,22 +75,67 @@ func main() {
 	}
 	slog.Info("database initialized", "path", cfg.General.DBPath)
 
+	// One-shot fix mode: rebuild positions/PnL from bet history, reconcile
+	// resolutions, print a fresh performance report, and exit.
+	if *fixFrom != "" {
+		since, err := time.Parse("2006-01-02", *fixFrom)
+		if err != nil {
+			slog.Error("invalid -fix-from date", "error", err)
+			os.Exit(1)
+		}
+		mc := mango.DefaultClientInstance()
+		fixer := performance.NewFixer(mc, database)
+		fixer.SetCircuitBreaker(risk.NewBetHistoryCircuitBreaker(database, cfg.Risk.CircuitBreaker))
+		if _, err := fixer.Run(since); err != nil {
+			slog.Error("fix failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// One-shot stats mode: recompute and print rolling TradeStats, then exit.
+	if *dumpStats {
+		tradeStats := performance.NewTradeStatsTracker(database, cfg.Performance.TradeStatsWindowSize, cfg.Performance.TradeStatsMinSample)
+		stats, err := tradeStats.Compute()
+		if err != nil {
+			slog.Error("computing trade stats failed", "error", err)
+			os.Exit(1)
+		}
+		performance.LogTradeStats(stats)
+		return
+	}
+
 	// Register strategies.
 	strategies := []strategy.Strategy{
 		strategy.NewArbitrage(cfg.Strategy.Arbitrage),
-		strategy.NewMispricing(cfg.Strategy.Mispricing),
-		strategy.NewTimeDecay(cfg.Strategy.TimeDecay),
+		strategy.NewMispricing(cfg.Strategy.Mispricing, database),
+		strategy.NewTimeDecay(cfg.Strategy.TimeDecay, database),
 		strategy.NewMarketMaking(cfg.Strategy.MarketMaking),
+		strategy.NewTrailingStop(cfg.Strategy.TrailingStop, database),
+		strategy.NewCrossMarketHedge(cfg.Strategy.CrossMarketHedge),
 	}
 	slog.Info("strategies registered", "count", len(strategies))
 
 	// Backtest mode.
 	if *backtestMode {
 		runner := backtest.NewRunner(database, strategies, cfg.Risk, *backtestBalance)
-		if err := runner.Run(*backtestFrom, *backtestTo); err != nil {
+		runner.SetAggregator(strategy.NewAggregator(cfg.Strategy.Aggregator))
+		runner.SetFillModel(backtest.NewCPMMFillModel(cfg.Backtest))
+		report, err := runner.Run(*backtestFrom, *backtestTo)
+		if err != nil {
 			slog.Error("backtest failed", "error", err)
 			os.Exit(1)
 		}
+		slog.Info("backtest report",
+			"total_bets", report.TotalBets,
+			"total_pnl", report.TotalPnL,
+			"roi", report.ROI,
+			"win_rate", report.WinRate,
+			"sharpe", report.Sharpe,
+			"sortino", report.Sortino,
+			"profit_factor", report.ProfitFactor,
+			"max_drawdown", report.MaxDrawdown,
+		)
 		return
 	}
 
@@ -90,14 +146,59 @@ func main() {
 	scanner := market.NewScanner(mc)
 	cache := market.NewCache(10 * time.Minute)
 	portfolio := risk.NewPortfolio(mc)
+	portfolio.SetFixer(database, risk.NewPortfolioFixer(mc, database), cfg.Risk.PortfolioFixTolerancePct)
 	riskMgr := risk.NewManager(cfg.Risk, portfolio)
+	breaker := risk.NewBetHistoryCircuitBreaker(database, cfg.Risk.CircuitBreaker)
+	riskMgr.SetCircuitBreaker(breaker)
+	riskMgr.SetDailyUsageDB(database)
+
+	// Strategies that emit fresh entry signals also consult the circuit
+	// breaker directly so they can skip or downscale while it's tripped;
+	// exit-only strategies like TrailingStop intentionally don't implement
+	// this, since closing a position should never be held back.
+	type circuitBreakerSetter interface {
+		SetCircuitBreaker(strategy.CircuitBreaker)
+	}
+	for _, s := range strategies {
+		if setter, ok := s.(circuitBreakerSetter); ok {
+			setter.SetCircuitBreaker(breaker)
+		}
+	}
+
+	// Those entry strategies, except TimeDecay, also consult their own
+	// rolling TradeStats to scale signals down (or to zero) when their
+	// realized track record has drifted from what they claimed at bet time.
+	tradeStats := performance.NewTradeStatsTracker(database, cfg.Performance.TradeStatsWindowSize, cfg.Performance.TradeStatsMinSample)
+	type performanceStatsSetter interface {
+		SetPerformanceStats(strategy.PerformanceStats)
+	}
+	for _, s := range strategies {
+		if setter, ok := s.(performanceStatsSetter); ok {
+			setter.SetPerformanceStats(tradeStats)
+		}
+	}
+
 	executor := execution.NewExecutor(mc, database)
+	orderBook := execution.NewActiveOrderBook(mc, database, cfg.Execution.StaleOrderMaxAge.Duration)
+	orderBook.Subscribe(
+		func(ev execution.OrderEvent) {
+			slog.Info("limit order fill observed", "market", ev.MarketID, "outcome", ev.Outcome, "status", ev.Status, "filled", ev.FilledAmount)
+		},
+		func(ev execution.OrderEvent) {
+			slog.Info("limit order cancelled as stale", "market", ev.MarketID, "outcome", ev.Outcome, "amount", ev.OrderAmount)
+		},
+	)
+	executor.SetOrderBook(orderBook)
 	coll := collector.NewCollector(scanner, database, cfg.Collector)
+	defer coll.Close()
 	tracker := performance.NewTracker(database)
+	aggregator := strategy.NewAggregator(cfg.Strategy.Aggregator)
+	hedger := hedging.NewHedger(cfg.Hedging)
+	hedger.SetRiskManager(riskMgr)
 
 	sched := scheduler.New(
-		scanner, cache, strategies, riskMgr, executor,
-		coll, tracker, portfolio, database, cfg.Schedule,
+		scanner, cache, strategies, aggregator, riskMgr, executor,
+		coll, tracker, tradeStats, portfolio, orderBook, hedger, database, cfg.Schedule,
 	)
 
 	// Graceful shutdown.
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
+)
+
+// runMigrateCLI implements `hyperkaehler migrate <up|down|status|create>`,
+// operating on the same database the bot itself uses
+// (general.db_path in config.toml, or HK_CONFIG_PATH), so schema changes
+// can be applied, inspected, or rolled back without hand-editing the
+// migration registry at the console.
+func runMigrateCLI(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hyperkaehler migrate <up|down|status|create> [args]")
+		os.Exit(1)
+	}
+
+	if args[0] == "create" {
+		runMigrateCreate(args[1:])
+		return
+	}
+
+	configPath := "config.toml"
+	if p := os.Getenv("HK_CONFIG_PATH"); p != "" {
+		configPath = p
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+	database, err := db.Open(cfg.General.DBPath)
+	if err != nil {
+		slog.Error("failed to open database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "up":
+		runMigrateUp(database, args[1:])
+	case "down":
+		runMigrateDown(database, args[1:])
+	case "status":
+		runMigrateStatus(database)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate subcommand %q\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func runMigrateUp(database *sql.DB, args []string) {
+	fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	target := fs.Int("target", 0, "Migrate up to this version (0 = latest)")
+	fs.Parse(args)
+
+	var err error
+	if *target == 0 {
+		err = db.Migrate(database)
+	} else {
+		err = db.MigrateTo(database, *target)
+	}
+	if err != nil {
+		slog.Error("migrate up failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("migrate up complete")
+}
+
+func runMigrateDown(database *sql.DB, args []string) {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "Number of migrations to roll back")
+	fs.Parse(args)
+
+	if err := db.Rollback(database, *steps); err != nil {
+		slog.Error("migrate down failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("migrate down complete", "steps", *steps)
+}
+
+func runMigrateStatus(database *sql.DB) {
+	statuses, err := db.Status(database)
+	if err != nil {
+		slog.Error("migrate status failed", "error", err)
+		os.Exit(1)
+	}
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied " + s.AppliedAt
+		}
+		fmt.Printf("%4d  %-60s  %s\n", s.Version, s.Description, state)
+	}
+}
+
+// runMigrateCreate doesn't scaffold a new file: this repo keeps its
+// migrations as an embedded []migration slice in internal/db/migrations.go
+// rather than one file per migration, so there's no per-migration file to
+// generate. Instead it prints the boilerplate for the next entry so a
+// developer can paste it in and fill out the Up/Down SQL by hand.
+func runMigrateCreate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hyperkaehler migrate create <name>")
+		os.Exit(1)
+	}
+	name := args[0]
+	fmt.Printf(`Add to internal/db/migrations.go (replace N with the next version number):
+
+	{N, %q, %sSQL, %sDownSQL},
+
+const %sSQL = `+"`"+`
+-- TODO
+`+"`"+`
+
+const %sDownSQL = `+"`"+`
+-- TODO
+`+"`"+`
+`, name, name, name, name, name)
+}
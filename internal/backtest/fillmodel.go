@@ -0,0 +1,139 @@
+package backtest
+
+import (
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/risk"
+	"hyperkaehler/internal/strategy"
+)
+
+// Fill describes the simulated outcome of trying to execute a signal.
+type Fill struct {
+	Filled   bool
+	FillProb float64 // probability/price the simulated order executed at
+}
+
+// FillModel decides whether and at what price a signal would have filled,
+// given the market it was signaled against and the probability trajectory
+// observed in later snapshots. It's handed the full risk.SizedSignal
+// rather than the bare strategy.Signal so a size-aware model (e.g.
+// CPMMFillModel, which walks the pool to a trade's actual size) can see
+// how much was staked. Swapping models lets a backtest compare, e.g., an
+// aggressive market-order strategy against a passive limit-order one, or a
+// zero-cost fill against a fee- and slippage-aware CPMM one, over the same
+// historical data.
+type FillModel interface {
+	Simulate(sig risk.SizedSignal, market strategy.MarketData, laterProbs []float64) Fill
+}
+
+// ImmediateFillModel fills every signal instantly at its recorded market
+// probability, as a market order would, ignoring fees and slippage.
+type ImmediateFillModel struct{}
+
+func (ImmediateFillModel) Simulate(sig risk.SizedSignal, market strategy.MarketData, laterProbs []float64) Fill {
+	return Fill{Filled: true, FillProb: sig.Signal.MarketProb}
+}
+
+// LimitFillModel only fills a limit order once a later snapshot shows the
+// market probability crossing the signal's LimitProb, mirroring how a
+// resting Manifold limit order actually fills. Non-limit signals fall back
+// to an immediate fill at MarketProb.
+type LimitFillModel struct{}
+
+func (LimitFillModel) Simulate(sig risk.SizedSignal, market strategy.MarketData, laterProbs []float64) Fill {
+	if !sig.Signal.IsLimitOrder {
+		return Fill{Filled: true, FillProb: sig.Signal.MarketProb}
+	}
+	for _, p := range laterProbs {
+		if limitCrossed(sig.Signal, p) {
+			return Fill{Filled: true, FillProb: sig.Signal.LimitProb}
+		}
+	}
+	return Fill{Filled: false}
+}
+
+// limitCrossed reports whether a later probability p would have matched a
+// resting limit order: a YES limit fills as the market price falls to or
+// below it, a NO limit fills as it rises to or above it.
+func limitCrossed(sig strategy.Signal, p float64) bool {
+	if sig.Outcome == "YES" {
+		return p <= sig.LimitProb
+	}
+	return p >= sig.LimitProb
+}
+
+// CPMMFillModel simulates a market order against the market's actual
+// poolYes/poolNo reserves, walking the constant-product invariant
+// poolYes*poolNo = k to find the average execution price across the trade
+// rather than assuming the whole order fills at the pre-trade mid
+// probability. It rejects the fill outright if the pool is too thin
+// (TotalLiquidity < cfg.MinLiquidityForFill) or if the trade would move the
+// price by more than cfg.MaxSlippagePct.
+type CPMMFillModel struct {
+	cfg config.BacktestConfig
+}
+
+func NewCPMMFillModel(cfg config.BacktestConfig) CPMMFillModel {
+	return CPMMFillModel{cfg: cfg}
+}
+
+func (m CPMMFillModel) Simulate(sig risk.SizedSignal, market strategy.MarketData, laterProbs []float64) Fill {
+	poolYes, poolNo := market.Pool["YES"], market.Pool["NO"]
+	if poolYes <= 0 || poolNo <= 0 {
+		// No pool data for this market (e.g. multiple choice) — fall back
+		// to an immediate fill rather than refusing to simulate it at all.
+		return Fill{Filled: true, FillProb: sig.Signal.MarketProb}
+	}
+	if market.TotalLiquidity < m.cfg.MinLiquidityForFill {
+		return Fill{Filled: false}
+	}
+
+	feeRate := m.cfg.TakerFeeRate
+	if sig.Signal.IsLimitOrder {
+		feeRate = m.cfg.MakerFeeRate
+	}
+	netAmount := sig.Amount * (1 - feeRate)
+	if netAmount <= 0 {
+		return Fill{Filled: false}
+	}
+
+	k := poolYes * poolNo
+	oldProb := poolNo / (poolYes + poolNo)
+
+	var sharesOut, newProb float64
+	if sig.Signal.Outcome == "YES" {
+		newPoolNo := poolNo + netAmount
+		newPoolYes := k / newPoolNo
+		// netAmount is added back in: the invariant swap only tells us how
+		// much the YES reserve was depleted, but the trader also receives
+		// the amount staked as shares outright, same as Manifold's cpmm-1.
+		sharesOut = netAmount + poolYes - newPoolYes
+		newProb = newPoolNo / (newPoolYes + newPoolNo)
+	} else {
+		newPoolYes := poolYes + netAmount
+		newPoolNo := k / newPoolYes
+		sharesOut = netAmount + poolNo - newPoolNo
+		newProb = newPoolNo / (newPoolYes + newPoolNo)
+	}
+	if sharesOut <= 0 {
+		return Fill{Filled: false}
+	}
+
+	slippage := newProb - oldProb
+	if sig.Signal.Outcome == "NO" {
+		slippage = -slippage
+	}
+	if m.cfg.MaxSlippagePct > 0 && oldProb > 0 && slippage/oldProb > m.cfg.MaxSlippagePct {
+		return Fill{Filled: false}
+	}
+
+	// avgFillProb is the average price paid per share across the whole
+	// trade, including the fee — this is what payout() uses to compute the
+	// simulated CPMM payout, so the fee shows up as a worse average price
+	// rather than a separate ledger line.
+	avgFillProb := sig.Amount / sharesOut
+	if avgFillProb <= 0 || avgFillProb >= 1 {
+		return Fill{Filled: false}
+	}
+
+	return Fill{Filled: true, FillProb: avgFillProb}
+}
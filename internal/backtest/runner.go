@@ -15,10 +15,12 @@ import (
 
 // Runner replays historical market snapshots through strategies to simulate trading.
 type Runner struct {
-	db         *sql.DB
-	strategies []strategy.Strategy
-	riskCfg    config.RiskConfig
+	db           *sql.DB
+	strategies   []strategy.Strategy
+	riskCfg      config.RiskConfig
 	startBalance float64
+	fillModel    FillModel
+	aggregator   *strategy.Aggregator
 }
 
 func NewRunner(db *sql.DB, strategies []strategy.Strategy, riskCfg config.RiskConfig, startBalance float64) *Runner {
@@ -27,14 +29,31 @@ func NewRunner(db *sql.DB, strategies []strategy.Strategy, riskCfg config.RiskCo
 		strategies:   strategies,
 		riskCfg:      riskCfg,
 		startBalance: startBalance,
+		fillModel:    ImmediateFillModel{},
 	}
 }
 
-// Run executes the backtest over the given date range.
-func (r *Runner) Run(fromStr, toStr string) error {
+// SetFillModel overrides how simulated orders fill. Defaults to
+// ImmediateFillModel, which fills everything at the signal's market
+// probability.
+func (r *Runner) SetFillModel(fm FillModel) {
+	r.fillModel = fm
+}
+
+// SetAggregator nets conflicting/reinforcing signals per market+answer
+// before sizing, the same way Scheduler does live. Unset (the default)
+// reproduces the old behavior of sizing every strategy's signal
+// independently.
+func (r *Runner) SetAggregator(agg *strategy.Aggregator) {
+	r.aggregator = agg
+}
+
+// Run executes the backtest over the given date range and returns a
+// per-strategy performance Report computed from simulated fills.
+func (r *Runner) Run(fromStr, toStr string) (*Report, error) {
 	from, to, err := parseDateRange(fromStr, toStr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	slog.Info("backtest starting", "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"), "balance", r.startBalance)
@@ -42,10 +61,10 @@ func (r *Runner) Run(fromStr, toStr string) error {
 	// Load all snapshot timestamps in the range.
 	timestamps, err := r.loadSnapshotTimestamps(from, to)
 	if err != nil {
-		return fmt.Errorf("loading snapshot timestamps: %w", err)
+		return nil, fmt.Errorf("loading snapshot timestamps: %w", err)
 	}
 	if len(timestamps) == 0 {
-		return fmt.Errorf("no market snapshots found in range %s to %s", fromStr, toStr)
+		return nil, fmt.Errorf("no market snapshots found in range %s to %s", fromStr, toStr)
 	}
 
 	slog.Info("loaded snapshot timestamps", "count", len(timestamps))
@@ -57,8 +76,7 @@ func (r *Runner) Run(fromStr, toStr string) error {
 	}
 	riskMgr := risk.NewManager(r.riskCfg, portfolio)
 
-	var totalBets int
-	var totalWagered float64
+	var trades []simulatedTrade
 
 	ctx := context.Background()
 
@@ -69,6 +87,10 @@ func (r *Runner) Run(fromStr, toStr string) error {
 			slog.Warn("failed to load markets at timestamp", "timestamp", ts, "error", err)
 			continue
 		}
+		marketsByID := make(map[string]strategy.MarketData, len(markets))
+		for _, m := range markets {
+			marketsByID[m.ID] = m
+		}
 
 		riskMgr.Refresh()
 
@@ -86,28 +108,134 @@ func (r *Runner) Run(fromStr, toStr string) error {
 			allSignals = append(allSignals, signals...)
 		}
 
+		if r.aggregator != nil {
+			allSignals = r.aggregator.Aggregate(allSignals)
+		}
+
 		// Size and simulate execution.
 		sized := riskMgr.SizeSignals(allSignals)
 		for _, sig := range sized {
-			totalBets++
-			totalWagered += sig.Amount
 			riskMgr.RecordTrade(sig.Signal.MarketID, sig.Amount)
 
-			// Record to backtest_bets table.
-			r.recordBacktestBet(sig, ts)
+			tr, fill, ok := r.simulateFill(sig, marketsByID[sig.Signal.MarketID], ts)
+			r.recordBacktestBet(sig, ts, fill)
+			if !ok {
+				continue
+			}
+			trades = append(trades, tr)
+
+			// Mark the virtual portfolio to market so subsequent Kelly
+			// sizing compounds off realized PnL instead of the static
+			// starting balance.
+			portfolio.Balance += tr.pnl
+			portfolio.TotalValue = portfolio.Balance
 		}
 	}
 
-	// Report results.
+	report := buildReport(trades)
+
 	slog.Info("=== BACKTEST RESULTS ===",
 		"period", fmt.Sprintf("%s to %s", from.Format("2006-01-02"), to.Format("2006-01-02")),
 		"snapshots_processed", len(timestamps),
-		"total_signals_placed", totalBets,
-		"total_mana_wagered", totalWagered,
+		"total_signals_placed", report.TotalBets,
+		"total_mana_wagered", report.TotalManaWagered,
+		"total_pnl", report.TotalPnL,
+		"roi", report.ROI,
+		"win_rate", report.WinRate,
 		"starting_balance", r.startBalance,
 	)
 
-	return nil
+	return report, nil
+}
+
+// StrategyVariant names a set of strategies to evaluate together in one
+// backtest pass, used by Sweep to compare config permutations side-by-side
+// (e.g. several Arbitrage instances built with different
+// MinProbSumDeviation values).
+type StrategyVariant struct {
+	Name       string
+	Strategies []strategy.Strategy
+}
+
+// Sweep runs the backtest once per variant over the same date range and
+// returns each one's Report keyed by variant name, so config parameters can
+// be compared without hand-editing config.toml between runs.
+func (r *Runner) Sweep(fromStr, toStr string, variants []StrategyVariant) (map[string]*Report, error) {
+	results := make(map[string]*Report, len(variants))
+	for _, v := range variants {
+		sub := NewRunner(r.db, v.Strategies, r.riskCfg, r.startBalance)
+		sub.SetFillModel(r.fillModel)
+		sub.aggregator = r.aggregator
+
+		report, err := sub.Run(fromStr, toStr)
+		if err != nil {
+			return nil, fmt.Errorf("sweep variant %q: %w", v.Name, err)
+		}
+		results[v.Name] = report
+		slog.Info("sweep variant complete", "variant", v.Name, "roi", report.ROI, "trades", report.TotalBets)
+	}
+	return results, nil
+}
+
+// simulateFill runs the configured FillModel for a sized signal and, if it
+// fills, scores it against the market's actual final resolution to produce
+// a simulatedTrade. It always returns the Fill itself (even an unfilled
+// one) so the caller can record it, and ok=false for unresolved markets or
+// orders that never fill.
+func (r *Runner) simulateFill(sig risk.SizedSignal, market strategy.MarketData, ts string) (simulatedTrade, Fill, bool) {
+	resolution, resolved := resolutionFor(sig.Signal, market)
+
+	var laterProbs []float64
+	if sig.Signal.IsLimitOrder {
+		var err error
+		laterProbs, err = r.loadLaterProbs(sig.Signal.MarketID, ts)
+		if err != nil {
+			slog.Warn("failed to load later probabilities for fill simulation", "market", sig.Signal.MarketID, "error", err)
+			return simulatedTrade{}, Fill{}, false
+		}
+	}
+
+	fill := r.fillModel.Simulate(sig, market, laterProbs)
+	if !fill.Filled || !resolved {
+		return simulatedTrade{}, fill, false
+	}
+
+	return simulatedTrade{
+		strategy: sig.Signal.Strategy,
+		amount:   sig.Amount,
+		pnl:      payout(sig.Signal.Outcome, resolution, sig.Amount, fill.FillProb),
+		edge:     sig.Signal.Edge,
+	}, fill, true
+}
+
+// resolutionFor looks up the actual resolved outcome for a signal's market
+// (or multiple-choice answer), reporting ok=false if it never resolved.
+func resolutionFor(sig strategy.Signal, market strategy.MarketData) (string, bool) {
+	if sig.AnswerID != "" {
+		for _, a := range market.Answers {
+			if a.ID == sig.AnswerID && a.Resolution != "" {
+				return a.Resolution, true
+			}
+		}
+		return "", false
+	}
+	if !market.IsResolved || market.Resolution == "" {
+		return "", false
+	}
+	return market.Resolution, true
+}
+
+// payout computes the realized PnL of a fixed-amount bet on outcome at
+// fillProb, given the market's actual resolution. A win returns the CPMM
+// payout (amount/fillProb) minus the stake; a loss forfeits the stake.
+func payout(outcome, resolution string, amount, fillProb float64) float64 {
+	if fillProb <= 0 || fillProb >= 1 {
+		fillProb = 0.5
+	}
+	if outcome == resolution {
+		return amount/fillProb - amount
+	}
+	return -amount
 }
 
 func parseDateRange(fromStr, toStr string) (time.Time, time.Time, error) {
@@ -136,6 +264,32 @@ func parseDateRange(fromStr, toStr string) (time.Time, time.Time, error) {
 	return from, to, nil
 }
 
+// loadLaterProbs returns the probability recorded in every snapshot for
+// marketID strictly after ts, in chronological order, for the LimitFillModel
+// to check against.
+func (r *Runner) loadLaterProbs(marketID, ts string) ([]float64, error) {
+	rows, err := r.db.Query(`
+		SELECT probability FROM market_snapshots
+		WHERE market_id = ? AND snapshot_at > ? AND probability IS NOT NULL
+		ORDER BY snapshot_at ASC`,
+		marketID, ts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var probs []float64
+	for rows.Next() {
+		var p float64
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		probs = append(probs, p)
+	}
+	return probs, rows.Err()
+}
+
 func (r *Runner) loadSnapshotTimestamps(from, to time.Time) ([]string, error) {
 	rows, err := r.db.Query(`
 		SELECT DISTINCT snapshot_at FROM market_snapshots
@@ -238,13 +392,21 @@ func (r *Runner) loadMarketsAtTimestamp(ts string) ([]strategy.MarketData, error
 	return markets, rows.Err()
 }
 
-func (r *Runner) recordBacktestBet(sig risk.SizedSignal, ts string) {
+// recordBacktestBet persists the sized signal and the fill the configured
+// FillModel produced for it. avg_fill_prob is left NULL when the order
+// never filled (e.g. rejected for thin liquidity or excessive slippage).
+func (r *Runner) recordBacktestBet(sig risk.SizedSignal, ts string, fill Fill) {
+	var avgFillProb sql.NullFloat64
+	if fill.Filled {
+		avgFillProb = sql.NullFloat64{Float64: fill.FillProb, Valid: true}
+	}
+
 	_, err := r.db.Exec(`
-		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction, placed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, avg_fill_prob, kelly_fraction, layer_index, placed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		sig.Signal.MarketID, sig.Signal.Strategy, sig.Signal.Outcome,
-		sig.Amount, sig.Signal.Confidence, sig.Signal.MarketProb,
-		0.0, ts,
+		sig.Amount, sig.Signal.Confidence, sig.Signal.MarketProb, avgFillProb,
+		0.0, sig.Signal.LayerIndex, ts,
 	)
 	if err != nil {
 		slog.Warn("failed to record backtest bet", "error", err)
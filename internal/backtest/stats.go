@@ -0,0 +1,197 @@
+package backtest
+
+import "math"
+
+// simulatedTrade is one filled signal's outcome against the market's actual
+// final resolution, used to build a Report without touching bot_bets.
+type simulatedTrade struct {
+	strategy string
+	amount   float64
+	pnl      float64
+	edge     float64
+}
+
+// Report is a per-backtest performance summary, computed the same way
+// performance.Tracker reports on live trading but from simulated fills.
+type Report struct {
+	TotalBets        int
+	TotalManaWagered float64
+	TotalPnL         float64
+	ROI              float64
+	WinRate          float64
+	MaxDrawdown      float64
+	Sharpe           float64
+	Sortino          float64
+	ProfitFactor     float64
+	StrategyStats    map[string]StrategyStats
+}
+
+// StrategyStats is the per-strategy slice of a Report.
+type StrategyStats struct {
+	BetCount     int
+	ManaWagered  float64
+	PnL          float64
+	ROI          float64
+	WinRate      float64
+	AvgEdge      float64
+	MaxDrawdown  float64
+	Sharpe       float64
+	Sortino      float64
+	ProfitFactor float64
+}
+
+// buildReport aggregates a flat list of simulated trades, in the order they
+// were placed, into an overall and per-strategy Report.
+func buildReport(trades []simulatedTrade) *Report {
+	r := &Report{StrategyStats: make(map[string]StrategyStats)}
+
+	byStrategy := make(map[string][]simulatedTrade)
+	for _, tr := range trades {
+		byStrategy[tr.strategy] = append(byStrategy[tr.strategy], tr)
+	}
+
+	r.TotalBets = len(trades)
+	summarize(trades, &r.TotalManaWagered, &r.TotalPnL, &r.ROI, &r.WinRate,
+		&r.MaxDrawdown, &r.Sharpe, &r.Sortino, &r.ProfitFactor)
+
+	for name, strategyTrades := range byStrategy {
+		var avgEdge float64
+		for _, tr := range strategyTrades {
+			avgEdge += tr.edge
+		}
+		avgEdge /= float64(len(strategyTrades))
+
+		stats := StrategyStats{BetCount: len(strategyTrades), AvgEdge: avgEdge}
+		summarize(strategyTrades, &stats.ManaWagered, &stats.PnL, &stats.ROI, &stats.WinRate,
+			&stats.MaxDrawdown, &stats.Sharpe, &stats.Sortino, &stats.ProfitFactor)
+		r.StrategyStats[name] = stats
+	}
+
+	return r
+}
+
+// summarize fills in the shared metrics (wagered, PnL, ROI, win rate,
+// drawdown, Sharpe, Sortino, profit factor) for any slice of trades, so the
+// overall and per-strategy Report fields are computed identically.
+func summarize(trades []simulatedTrade, wagered, pnl, roi, winRate, maxDrawdown, sharpe, sortino, profitFactor *float64) {
+	if len(trades) == 0 {
+		return
+	}
+
+	var wins int
+	var grossProfit, grossLoss float64
+	returns := make([]float64, 0, len(trades))
+
+	for _, tr := range trades {
+		*wagered += tr.amount
+		*pnl += tr.pnl
+		if tr.pnl > 0 {
+			wins++
+			grossProfit += tr.pnl
+		} else if tr.pnl < 0 {
+			grossLoss += -tr.pnl
+		}
+		if tr.amount > 0 {
+			returns = append(returns, tr.pnl/tr.amount)
+		}
+	}
+
+	if *wagered > 0 {
+		*roi = *pnl / *wagered
+	}
+	*winRate = float64(wins) / float64(len(trades))
+	*maxDrawdown = equityDrawdown(trades)
+	*sharpe = sharpeRatio(returns)
+	*sortino = sortinoRatio(returns)
+	if grossLoss > 0 {
+		*profitFactor = grossProfit / grossLoss
+	}
+}
+
+// equityDrawdown replays trade PnL in placement order against a notional
+// starting equity of 1.0 and returns the largest peak-to-trough decline.
+func equityDrawdown(trades []simulatedTrade) float64 {
+	equity := 1.0
+	peak := equity
+	var maxDD float64
+	for _, tr := range trades {
+		equity += tr.pnl
+		if equity > peak {
+			peak = equity
+		}
+		if peak > 0 {
+			dd := (peak - equity) / peak
+			maxDD = math.Max(maxDD, dd)
+		}
+	}
+	return maxDD
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func stddev(xs []float64, m float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sumSq float64
+	for _, x := range xs {
+		d := x - m
+		sumSq += d * d
+	}
+	return math.Sqrt(sumSq / float64(len(xs)))
+}
+
+// minStddev is the standard-deviation floor below which sharpeRatio and sortinoRatio
+// treat returns as constant and report 0 rather than dividing by a
+// near-zero standard deviation. Floating-point mean/variance arithmetic
+// leaves a residual of this order even for truly constant inputs, so an
+// exact sd == 0 check doesn't catch them.
+const minStddev = 1e-12
+
+// sharpeRatio is the mean per-bet return over its standard deviation, a
+// simple (non-annualized) risk-adjusted return measure appropriate for a
+// fixed batch of historical bets rather than a time series.
+func sharpeRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	m := mean(returns)
+	sd := stddev(returns, m)
+	if sd < minStddev {
+		return 0
+	}
+	return m / sd
+}
+
+// sortinoRatio is like sharpeRatio but only penalizes downside volatility
+// (negative returns), rewarding strategies whose variance comes from upside.
+func sortinoRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	m := mean(returns)
+
+	var downside []float64
+	for _, r := range returns {
+		if r < 0 {
+			downside = append(downside, r)
+		}
+	}
+	if len(downside) == 0 {
+		return 0
+	}
+	dd := stddev(downside, 0)
+	if dd < minStddev {
+		return 0
+	}
+	return m / dd
+}
@@ -0,0 +1,167 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/risk"
+	"hyperkaehler/internal/strategy"
+)
+
+func TestBuildReport_ComputesOverallAndPerStrategyStats(t *testing.T) {
+	trades := []simulatedTrade{
+		{strategy: "arbitrage", amount: 10, pnl: 5, edge: 0.10},
+		{strategy: "arbitrage", amount: 10, pnl: -10, edge: 0.05},
+		{strategy: "timedecay", amount: 20, pnl: 8, edge: 0.08},
+	}
+
+	report := buildReport(trades)
+
+	if report.TotalBets != 3 {
+		t.Errorf("expected 3 total bets, got %d", report.TotalBets)
+	}
+	if report.TotalManaWagered != 40 {
+		t.Errorf("expected 40 total wagered, got %f", report.TotalManaWagered)
+	}
+	if report.TotalPnL != 3 {
+		t.Errorf("expected total PnL 3, got %f", report.TotalPnL)
+	}
+
+	arb, ok := report.StrategyStats["arbitrage"]
+	if !ok {
+		t.Fatal("expected arbitrage strategy stats")
+	}
+	if arb.BetCount != 2 {
+		t.Errorf("expected 2 arbitrage bets, got %d", arb.BetCount)
+	}
+	if arb.WinRate != 0.5 {
+		t.Errorf("expected arbitrage win rate 0.5, got %f", arb.WinRate)
+	}
+	if arb.ProfitFactor != 0.5 {
+		t.Errorf("expected arbitrage profit factor 0.5, got %f", arb.ProfitFactor)
+	}
+}
+
+func TestSharpeRatio_ZeroVarianceIsZero(t *testing.T) {
+	returns := []float64{0.1, 0.1, 0.1}
+	if got := sharpeRatio(returns); got != 0 {
+		t.Errorf("expected sharpe 0 for zero-variance returns, got %f", got)
+	}
+}
+
+func TestSortinoRatio_IgnoresUpsideVolatility(t *testing.T) {
+	returns := []float64{1.0, 2.0, -0.1}
+	if got := sortinoRatio(returns); got <= 0 {
+		t.Errorf("expected positive sortino ratio with small downside, large upside, got %f", got)
+	}
+}
+
+func TestEquityDrawdown_TracksPeakToTrough(t *testing.T) {
+	trades := []simulatedTrade{
+		{amount: 1, pnl: 0.5},  // equity 1.5, new peak
+		{amount: 1, pnl: -1.0}, // equity 0.5, drawdown from 1.5 = 2/3
+		{amount: 1, pnl: 0.2},  // equity 0.7
+	}
+	dd := equityDrawdown(trades)
+	want := (1.5 - 0.5) / 1.5
+	if math.Abs(dd-want) > 1e-9 {
+		t.Errorf("expected max drawdown %f, got %f", want, dd)
+	}
+}
+
+func TestPayout_WinsAndLosses(t *testing.T) {
+	if got := payout("YES", "YES", 10, 0.5); got != 10 {
+		t.Errorf("expected payout 10 for a winning even-money bet, got %f", got)
+	}
+	if got := payout("YES", "NO", 10, 0.5); got != -10 {
+		t.Errorf("expected -10 for a losing bet, got %f", got)
+	}
+}
+
+func TestResolutionFor_BinaryAndMultipleChoice(t *testing.T) {
+	market := strategy.MarketData{
+		IsResolved: true,
+		Resolution: "YES",
+		Answers: []strategy.AnswerData{
+			{ID: "a1", Resolution: "NO"},
+			{ID: "a2", Resolution: "YES"},
+		},
+	}
+
+	if res, ok := resolutionFor(strategy.Signal{MarketID: "m1"}, market); !ok || res != "YES" {
+		t.Errorf("expected binary resolution YES, got %q (ok=%v)", res, ok)
+	}
+	if res, ok := resolutionFor(strategy.Signal{MarketID: "m1", AnswerID: "a2"}, market); !ok || res != "YES" {
+		t.Errorf("expected answer a2 resolution YES, got %q (ok=%v)", res, ok)
+	}
+	if _, ok := resolutionFor(strategy.Signal{MarketID: "m1", AnswerID: "unknown"}, market); ok {
+		t.Error("expected unresolved answer to report ok=false")
+	}
+}
+
+func TestLimitFillModel_FillsOnlyWhenProbabilityCrosses(t *testing.T) {
+	fm := LimitFillModel{}
+	sig := risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", IsLimitOrder: true, LimitProb: 0.40, MarketProb: 0.50}}
+
+	if fill := fm.Simulate(sig, strategy.MarketData{}, []float64{0.48, 0.45}); fill.Filled {
+		t.Error("expected no fill before probability crosses the limit")
+	}
+	if fill := fm.Simulate(sig, strategy.MarketData{}, []float64{0.48, 0.38}); !fill.Filled || fill.FillProb != 0.40 {
+		t.Errorf("expected fill at limit price 0.40 once crossed, got %+v", fill)
+	}
+}
+
+func TestImmediateFillModel_AlwaysFills(t *testing.T) {
+	fm := ImmediateFillModel{}
+	sig := risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", MarketProb: 0.62}}
+	fill := fm.Simulate(sig, strategy.MarketData{}, nil)
+	if !fill.Filled || fill.FillProb != 0.62 {
+		t.Errorf("expected immediate fill at market prob 0.62, got %+v", fill)
+	}
+}
+
+func TestCPMMFillModel_WorsensPriceWithSize(t *testing.T) {
+	fm := NewCPMMFillModel(config.BacktestConfig{TakerFeeRate: 0, MinLiquidityForFill: 0, MaxSlippagePct: 1})
+	market := strategy.MarketData{
+		Pool:           map[string]float64{"YES": 1000, "NO": 1000},
+		TotalLiquidity: 1000,
+		Probability:    0.50,
+	}
+
+	small := fm.Simulate(risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", MarketProb: 0.50}, Amount: 10}, market, nil)
+	large := fm.Simulate(risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", MarketProb: 0.50}, Amount: 400}, market, nil)
+
+	if !small.Filled || !large.Filled {
+		t.Fatalf("expected both trades to fill, got small=%+v large=%+v", small, large)
+	}
+	if large.FillProb <= small.FillProb {
+		t.Errorf("expected a larger YES buy to pay a worse average price, got small=%.4f large=%.4f", small.FillProb, large.FillProb)
+	}
+}
+
+func TestCPMMFillModel_RejectsOnThinLiquidity(t *testing.T) {
+	fm := NewCPMMFillModel(config.BacktestConfig{TakerFeeRate: 0, MinLiquidityForFill: 100, MaxSlippagePct: 1})
+	market := strategy.MarketData{
+		Pool:           map[string]float64{"YES": 1000, "NO": 1000},
+		TotalLiquidity: 50,
+	}
+
+	fill := fm.Simulate(risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", MarketProb: 0.50}, Amount: 10}, market, nil)
+	if fill.Filled {
+		t.Error("expected fill to be rejected when TotalLiquidity is below MinLiquidityForFill")
+	}
+}
+
+func TestCPMMFillModel_RejectsExcessiveSlippage(t *testing.T) {
+	fm := NewCPMMFillModel(config.BacktestConfig{TakerFeeRate: 0, MinLiquidityForFill: 0, MaxSlippagePct: 0.01})
+	market := strategy.MarketData{
+		Pool:           map[string]float64{"YES": 1000, "NO": 1000},
+		TotalLiquidity: 1000,
+	}
+
+	fill := fm.Simulate(risk.SizedSignal{Signal: strategy.Signal{Outcome: "YES", MarketProb: 0.50}, Amount: 900}, market, nil)
+	if fill.Filled {
+		t.Error("expected a trade moving price far beyond MaxSlippagePct to be rejected")
+	}
+}
@@ -7,19 +7,35 @@ import (
 	"log/slog"
 
 	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
 	"hyperkaehler/internal/market"
 	"hyperkaehler/internal/strategy"
 )
 
-// Collector periodically snapshots market data for backtesting.
+// Collector periodically snapshots market data for backtesting. Snapshots
+// are written through a db.SnapshotWriter rather than one INSERT per
+// market: at cfg.MaxMarketsPerScan markets per poll, per-row inserts would
+// make snapshotting, not scanning, the collection loop's bottleneck.
 type Collector struct {
-	scanner *market.Scanner
-	db      *sql.DB
-	cfg     config.CollectorConfig
+	scanner   *market.Scanner
+	db        *sql.DB
+	cfg       config.CollectorConfig
+	snapshots *db.SnapshotWriter
 }
 
-func NewCollector(scanner *market.Scanner, db *sql.DB, cfg config.CollectorConfig) *Collector {
-	return &Collector{scanner: scanner, db: db, cfg: cfg}
+func NewCollector(scanner *market.Scanner, database *sql.DB, cfg config.CollectorConfig) *Collector {
+	return &Collector{
+		scanner:   scanner,
+		db:        database,
+		cfg:       cfg,
+		snapshots: db.NewSnapshotWriter(database, cfg.SnapshotBatchSize, cfg.SnapshotFlushInterval.Duration),
+	}
+}
+
+// Close flushes any snapshots still pending in the SnapshotWriter and
+// stops its background goroutine. Call once, during shutdown.
+func (c *Collector) Close() error {
+	return c.snapshots.Close()
 }
 
 // Collect fetches markets and stores snapshots.
@@ -42,11 +58,8 @@ func (c *Collector) Collect() error {
 		}
 		inserted++
 
-		// Take snapshot.
-		if err := c.snapshot(m); err != nil {
-			slog.Warn("failed to snapshot market", "id", m.ID, "error", err)
-			continue
-		}
+		// Enqueue snapshot; SnapshotWriter batches it into a later flush.
+		c.snapshot(m)
 		snapshotted++
 	}
 
@@ -69,7 +82,7 @@ func (c *Collector) upsertMarket(m strategy.MarketData) error {
 	return err
 }
 
-func (c *Collector) snapshot(m strategy.MarketData) error {
+func (c *Collector) snapshot(m strategy.MarketData) {
 	var answerProbs *string
 	if len(m.Answers) > 0 {
 		probs := make(map[string]float64, len(m.Answers))
@@ -91,12 +104,16 @@ func (c *Collector) snapshot(m strategy.MarketData) error {
 		poolNo = &v
 	}
 
-	_, err := c.db.Exec(`
-		INSERT INTO market_snapshots (market_id, probability, answer_probs, volume, volume_24h, total_liquidity, pool_yes, pool_no)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		m.ID, m.Probability, answerProbs, m.Volume, m.Volume24Hours, m.TotalLiquidity, poolYes, poolNo,
-	)
-	return err
+	c.snapshots.Write(db.Snapshot{
+		MarketID:       m.ID,
+		Probability:    m.Probability,
+		AnswerProbs:    answerProbs,
+		Volume:         m.Volume,
+		Volume24h:      m.Volume24Hours,
+		TotalLiquidity: m.TotalLiquidity,
+		PoolYes:        poolYes,
+		PoolNo:         poolNo,
+	})
 }
 
 func boolToInt(b bool) int {
@@ -9,11 +9,60 @@ import (
 )
 
 type Config struct {
-	General  GeneralConfig  `toml:"general"`
-	Schedule ScheduleConfig `toml:"schedule"`
-	Risk     RiskConfig     `toml:"risk"`
-	Strategy StrategyConfig `toml:"strategy"`
-	Collector CollectorConfig `toml:"collector"`
+	General     GeneralConfig     `toml:"general"`
+	Schedule    ScheduleConfig    `toml:"schedule"`
+	Risk        RiskConfig        `toml:"risk"`
+	Strategy    StrategyConfig    `toml:"strategy"`
+	Collector   CollectorConfig   `toml:"collector"`
+	Performance PerformanceConfig `toml:"performance"`
+	Execution   ExecutionConfig   `toml:"execution"`
+	Hedging     HedgingConfig     `toml:"hedging"`
+	Backtest    BacktestConfig    `toml:"backtest"`
+}
+
+// BacktestConfig tunes backtest.Runner's CPMM fill simulation: trading
+// fees, the minimum pool liquidity a signal can fill against, and how much
+// the simulated trade is allowed to move the market before it's rejected as
+// unfillable rather than silently filled at an unrealistic price.
+type BacktestConfig struct {
+	MakerFeeRate        float64 `toml:"maker_fee_rate"`
+	TakerFeeRate        float64 `toml:"taker_fee_rate"`
+	MinLiquidityForFill float64 `toml:"min_liquidity_for_fill"`
+	MaxSlippagePct      float64 `toml:"max_slippage_pct"`
+}
+
+// HedgingConfig configures hedging.Hedger, which opens proportional hedge
+// bets in related markets whenever a signal is sized on a declared primary
+// market.
+type HedgingConfig struct {
+	Enabled bool         `toml:"enabled"`
+	Groups  []HedgeGroup `toml:"groups"`
+}
+
+// HedgeGroup declares that a sized signal on Primary should be followed by
+// a proportional bet on each of Hedges.
+type HedgeGroup struct {
+	Primary string     `toml:"primary"`
+	Hedges  []HedgeLeg `toml:"hedges"`
+}
+
+// HedgeLeg is one market to hedge into: Outcome/MarketID identify the bet,
+// Ratio scales it against the primary signal's sized amount, and
+// Correlation is carried through to the emitted signal's Reason for
+// observability — it isn't otherwise used for sizing.
+type HedgeLeg struct {
+	MarketID    string  `toml:"market_id"`
+	Outcome     string  `toml:"outcome"`
+	Ratio       float64 `toml:"ratio"`
+	Correlation float64 `toml:"correlation"`
+}
+
+// ExecutionConfig configures execution.ActiveOrderBook's reconciliation of
+// resting limit orders.
+type ExecutionConfig struct {
+	// StaleOrderMaxAge is how long a limit order may rest unfilled before
+	// Reconcile cancels it to free up the capital it's holding.
+	StaleOrderMaxAge Duration `toml:"stale_order_max_age"`
 }
 
 type GeneralConfig struct {
@@ -29,20 +78,115 @@ type ScheduleConfig struct {
 }
 
 type RiskConfig struct {
-	KellyFraction       float64 `toml:"kelly_fraction"`
-	MaxPositionPct      float64 `toml:"max_position_pct"`
-	MaxMarketExposurePct float64 `toml:"max_market_exposure_pct"`
-	MaxTotalExposure    float64 `toml:"max_total_exposure"`
-	MaxDrawdownPct      float64 `toml:"max_drawdown_pct"`
-	MinBetAmount        float64 `toml:"min_bet_amount"`
-	MinEdge             float64 `toml:"min_edge"`
+	KellyFraction        float64              `toml:"kelly_fraction"`
+	MaxPositionPct       float64              `toml:"max_position_pct"`
+	MaxMarketExposurePct float64              `toml:"max_market_exposure_pct"`
+	MaxTotalExposure     float64              `toml:"max_total_exposure"`
+	MaxDrawdownPct       float64              `toml:"max_drawdown_pct"`
+	MinBetAmount         float64              `toml:"min_bet_amount"`
+	MinEdge              float64              `toml:"min_edge"`
+	CircuitBreaker       CircuitBreakerConfig `toml:"circuit_breaker"`
+	// PortfolioFixTolerancePct is the fractional disagreement between the
+	// DB's recorded investment value and the API's before Portfolio.Refresh
+	// opportunistically runs the PortfolioFixer. 0 disables the check.
+	PortfolioFixTolerancePct float64 `toml:"portfolio_fix_tolerance_pct"`
+
+	// DailyManaBudget, DailyMaxBets, and DailyMaxVolume cap total spend,
+	// bet count, and wagered volume per UTC day. 0 disables the
+	// corresponding check. All three reset at DailyResetHourUTC.
+	DailyManaBudget   float64 `toml:"daily_mana_budget"`
+	DailyMaxBets      int     `toml:"daily_max_bets"`
+	DailyMaxVolume    float64 `toml:"daily_max_volume"`
+	DailyResetHourUTC int     `toml:"daily_reset_hour_utc"`
+
+	// StrategyWeights maps strategy name -> relative weight used by
+	// risk.Manager.SizeSignals when it nets multiple strategies'
+	// SignalNumber together for the same market. A strategy missing from
+	// the map defaults to a weight of 1.0.
+	StrategyWeights map[string]float64 `toml:"strategy_weights"`
+	// MaxEdge scales a market's netted SignalNumber into a Confidence:
+	// marketProb + signalNumber*MaxEdge, clamped to (0,1). It is the most
+	// edge a unanimous, maximally-confident group of strategies can claim
+	// over the market price.
+	MaxEdge float64 `toml:"max_edge"`
+	// SignalTTL decays a signal's contribution to its market's netted
+	// SignalNumber linearly to zero as it ages past this duration. 0
+	// disables decay, so every signal counts in full regardless of age.
+	SignalTTL Duration `toml:"signal_ttl"`
+}
+
+// CircuitBreakerConfig configures the thresholds that halt new trade
+// execution in risk.Manager while still allowing scans and snapshots.
+type CircuitBreakerConfig struct {
+	Enabled              bool     `toml:"enabled"`
+	MaxConsecutiveLosses int      `toml:"max_consecutive_losses"`
+	MaxLossMana          float64  `toml:"max_loss_mana"`
+	LossWindow           Duration `toml:"loss_window"`
+	MaxDrawdownPct       float64  `toml:"max_drawdown_pct"`
+	CoolOff              Duration `toml:"cool_off"`
+
+	// PerStrategyMaxConsecutiveLosses trips the breaker when a single
+	// strategy, rather than the portfolio as a whole, racks up this many
+	// consecutive losing bets. 0 disables the check.
+	PerStrategyMaxConsecutiveLosses int `toml:"per_strategy_max_consecutive_losses"`
+	// SoftTripDownscaleFactor, if greater than 0, is applied by strategies to
+	// Confidence and Edge instead of skipping evaluation outright while the
+	// breaker is tripped. 0 (the default) means strategies skip entirely.
+	SoftTripDownscaleFactor float64 `toml:"soft_trip_downscale_factor"`
+
+	// MaxBetsPerWindow and RapidFireWindow trip the breaker when more than
+	// MaxBetsPerWindow bets were placed within the trailing RapidFireWindow,
+	// regardless of outcome — a guard against a misbehaving strategy or a
+	// feedback loop firing off bets far faster than intended. 0 disables
+	// the check.
+	MaxBetsPerWindow int      `toml:"max_bets_per_window"`
+	RapidFireWindow  Duration `toml:"rapid_fire_window"`
 }
 
 type StrategyConfig struct {
-	Arbitrage    ArbitrageConfig    `toml:"arbitrage"`
-	Mispricing   MispricingConfig   `toml:"mispricing"`
-	TimeDecay    TimeDecayConfig    `toml:"timedecay"`
-	MarketMaking MarketMakingConfig `toml:"marketmaking"`
+	Arbitrage        ArbitrageConfig        `toml:"arbitrage"`
+	Mispricing       MispricingConfig       `toml:"mispricing"`
+	TimeDecay        TimeDecayConfig        `toml:"timedecay"`
+	MarketMaking     MarketMakingConfig     `toml:"marketmaking"`
+	TrailingStop     TrailingStopConfig     `toml:"trailingstop"`
+	CrossMarketHedge CrossMarketHedgeConfig `toml:"crossmarkethedge"`
+	Aggregator       AggregatorConfig       `toml:"aggregator"`
+}
+
+// AggregatorConfig configures how strategy.Aggregator combines signals from
+// multiple strategies targeting the same (market, answer) into one.
+type AggregatorConfig struct {
+	// Weights maps strategy name -> relative weight. A strategy missing from
+	// the map defaults to a weight of 1.0.
+	Weights map[string]float64 `toml:"weights"`
+	// MinNetConfidence is the minimum absolute net confidence (after
+	// weighting) required to emit a consolidated signal; conflicting
+	// signals that net out below this are dropped.
+	MinNetConfidence float64 `toml:"min_net_confidence"`
+}
+
+// TrailingStopConfig mirrors bollmaker's tiered trailing-stop shape:
+// parallel arrays where activation[i]/callback[i] form one tier. Once a
+// position's favorable probability move crosses activation[i], a
+// retracement of callback[i] from the peak closes it.
+type TrailingStopConfig struct {
+	Enabled                  bool      `toml:"enabled"`
+	TrailingActivationRatios []float64 `toml:"trailing_activation_ratios"`
+	TrailingCallbackRates    []float64 `toml:"trailing_callback_rates"`
+
+	// ROITakeProfitPct and ROIStopLossPct are hard mark-to-market ROI
+	// thresholds checked ahead of the trailing-stop tiers: a position whose
+	// ROI crosses either exits immediately, regardless of whether a
+	// trailing stop has armed. 0 disables the respective check.
+	ROITakeProfitPct float64 `toml:"roi_take_profit_pct"`
+	ROIStopLossPct   float64 `toml:"roi_stop_loss_pct"`
+
+	// TimeExitHoursBeforeClose, if greater than 0, exits any still-open
+	// position once the market is within that many hours of CloseTime,
+	// regardless of ROI or trailing-tier state — avoids riding a position
+	// into the volatility and thin liquidity that often show up right
+	// before a market closes. 0 disables the check.
+	TimeExitHoursBeforeClose float64 `toml:"time_exit_hours_before_close"`
 }
 
 type ArbitrageConfig struct {
@@ -60,26 +204,105 @@ type MispricingConfig struct {
 	MinMarketAgeDays       int     `toml:"min_market_age_days"`
 	MinVolume              float64 `toml:"min_volume"`
 	MeanReversionThreshold float64 `toml:"mean_reversion_threshold"`
+
+	// SuddenMoveThreshold is the minimum absolute probability move (e.g.
+	// 0.15 for 15 points) within SuddenMoveWindow that qualifies as a
+	// "sudden move" candidate for mean reversion.
+	SuddenMoveThreshold float64 `toml:"sudden_move_threshold"`
+	// SuddenMoveWindow is how far back to look for the pre-move probability.
+	SuddenMoveWindow Duration `toml:"sudden_move_window"`
+	// MinVolumeConfirm is the volume traded since the move that, if met or
+	// exceeded, treats the move as confirmed by real activity rather than a
+	// mispricing to revert.
+	MinVolumeConfirm float64 `toml:"min_volume_confirm"`
+	// ReversionCooldown is how long to wait before re-evaluating a market
+	// for another reversion signal once one has fired.
+	ReversionCooldown Duration `toml:"reversion_cooldown"`
 }
 
 type TimeDecayConfig struct {
-	Enabled                 bool    `toml:"enabled"`
-	MinTimeElapsedFraction  float64 `toml:"min_time_elapsed_fraction"`
-	MinEdge                 float64 `toml:"min_edge"`
-	MinVolume               float64 `toml:"min_volume"`
+	Enabled                bool    `toml:"enabled"`
+	MinTimeElapsedFraction float64 `toml:"min_time_elapsed_fraction"`
+	MinEdge                float64 `toml:"min_edge"`
+	MinVolume              float64 `toml:"min_volume"`
+	// MinCalibrationSamples is the total resolved-market snapshot count
+	// across all deciles (a market counts once per snapshot, not once per
+	// market) below which evaluateMarket falls back to the hard-coded
+	// linear decay factor rather than trusting a thin calibration.
+	MinCalibrationSamples int `toml:"min_calibration_samples"`
+	// CalibrationInterval is how often the decay curve is refit from
+	// resolved-market history. 0 falls back to defaultCalibrationInterval.
+	CalibrationInterval Duration `toml:"calibration_interval"`
 }
 
 type MarketMakingConfig struct {
-	Enabled                  bool    `toml:"enabled"`
-	BaseSpread               float64 `toml:"base_spread"`
-	MinLiquidity             float64 `toml:"min_liquidity"`
-	MinVolume24h             float64 `toml:"min_volume_24h"`
-	MaxLimitOrderCapitalPct  float64 `toml:"max_limit_order_capital_pct"`
+	Enabled                 bool    `toml:"enabled"`
+	BaseSpread              float64 `toml:"base_spread"`
+	MinLiquidity            float64 `toml:"min_liquidity"`
+	MinVolume24h            float64 `toml:"min_volume_24h"`
+	// MaxLimitOrderCapitalPct bounds the ladder's summed SizeMultiplier
+	// against this fraction of the market's TotalLiquidity: despite the
+	// name, it's a liquidity-scaled ceiling on the dimensionless multiplier
+	// sum, not a mana budget — the real mana amount per layer isn't known
+	// until risk.Manager sizes it later via Kelly, well after this strategy
+	// has returned its signals. risk.Manager's MaxMarketExposurePct is what
+	// actually caps mana committed to a market.
+	MaxLimitOrderCapitalPct float64 `toml:"max_limit_order_capital_pct"`
+	// NumLayers is the number of limit orders to ladder on each side of the
+	// spread. 1 reproduces the original single YES/NO pair.
+	NumLayers int `toml:"num_layers"`
+	// LayerSpread is the extra probability offset added per layer beyond
+	// BaseSpread/2, e.g. layer i sits at halfSpread + i*LayerSpread.
+	LayerSpread float64 `toml:"layer_spread"`
+	// QuantityMultiplier is the per-layer size ramp, interpreted according
+	// to LayerSizeMode: geometrically (layer i gets QuantityMultiplier^i
+	// times the base layer's size) or arithmetically (layer i gets
+	// 1+i*(QuantityMultiplier-1) times the base layer's size).
+	QuantityMultiplier float64 `toml:"quantity_multiplier"`
+	// LayerSizeMode selects how QuantityMultiplier ramps layer size:
+	// "geometric" (the default) or "arithmetic".
+	LayerSizeMode string `toml:"layer_size_mode"`
+}
+
+// CrossMarketHedgeConfig configures strategy.CrossMarketHedge, which pairs
+// up near-duplicate markets tracking the same underlying event and hedges
+// between them when their prices diverge.
+type CrossMarketHedgeConfig struct {
+	Enabled bool `toml:"enabled"`
+	// SimilarityThreshold is the minimum normalized-token Jaccard similarity
+	// between two market questions for them to be treated as duplicates.
+	// 0 disables similarity-based grouping entirely, leaving only MarketGroups.
+	SimilarityThreshold float64 `toml:"similarity_threshold"`
+	// MinSpread is the minimum probability gap between two markets in the
+	// same group required to emit a hedge pair.
+	MinSpread float64 `toml:"min_spread"`
+	// MarketGroups explicitly declares market IDs that track the same event,
+	// keyed by an arbitrary group name. Markets named here are grouped
+	// as-is, taking priority over similarity-based grouping.
+	MarketGroups map[string][]string `toml:"market_groups"`
 }
 
 type CollectorConfig struct {
 	MaxMarketsPerScan int     `toml:"max_markets_per_scan"`
 	MinLiquidity      float64 `toml:"min_liquidity"`
+
+	// SnapshotBatchSize and SnapshotFlushInterval tune the db.SnapshotWriter
+	// that Collector writes market_snapshots rows through. 0 falls back to
+	// db.DefaultSnapshotBatchSize / db.DefaultSnapshotFlushInterval.
+	SnapshotBatchSize     int      `toml:"snapshot_batch_size"`
+	SnapshotFlushInterval Duration `toml:"snapshot_flush_interval"`
+}
+
+// PerformanceConfig configures performance.TradeStatsTracker, the rolling
+// realized track record strategies consult to scale their own signal sizing.
+type PerformanceConfig struct {
+	// TradeStatsWindowSize is how many of a strategy's most recent resolved
+	// bets its rolling TradeStats are computed over. 0 falls back to 100.
+	TradeStatsWindowSize int `toml:"trade_stats_window_size"`
+	// TradeStatsMinSample is the minimum resolved-bet count required before
+	// a strategy's multiplier deviates from 1.0 (no adjustment) — below it
+	// the sample is too small to trust.
+	TradeStatsMinSample int `toml:"trade_stats_min_sample"`
 }
 
 // Duration wraps time.Duration for TOML unmarshaling.
@@ -120,14 +343,30 @@ func DefaultConfig() *Config {
 			PerformanceInterval:  Duration{1 * time.Hour},
 			OrderCleanupInterval: Duration{10 * time.Minute},
 		},
+		Execution: ExecutionConfig{
+			StaleOrderMaxAge: Duration{30 * time.Minute},
+		},
 		Risk: RiskConfig{
-			KellyFraction:        0.25,
-			MaxPositionPct:       0.05,
-			MaxMarketExposurePct: 0.10,
-			MaxTotalExposure:     0.50,
-			MaxDrawdownPct:       0.20,
-			MinBetAmount:         1.0,
-			MinEdge:              0.05,
+			KellyFraction:            0.25,
+			MaxPositionPct:           0.05,
+			MaxMarketExposurePct:     0.10,
+			MaxTotalExposure:         0.50,
+			MaxDrawdownPct:           0.20,
+			MinBetAmount:             1.0,
+			MinEdge:                  0.05,
+			PortfolioFixTolerancePct: 0.10,
+			MaxEdge:                  0.20,
+			SignalTTL:                Duration{30 * time.Minute},
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:              false,
+				MaxConsecutiveLosses: 5,
+				MaxLossMana:          500,
+				LossWindow:           Duration{24 * time.Hour},
+				MaxDrawdownPct:       0.25,
+				CoolOff:              Duration{6 * time.Hour},
+				MaxBetsPerWindow:     20,
+				RapidFireWindow:      Duration{5 * time.Minute},
+			},
 		},
 		Strategy: StrategyConfig{
 			Arbitrage: ArbitrageConfig{
@@ -138,8 +377,20 @@ func DefaultConfig() *Config {
 			},
 		},
 		Collector: CollectorConfig{
-			MaxMarketsPerScan: 500,
-			MinLiquidity:      20.0,
+			MaxMarketsPerScan:     500,
+			MinLiquidity:          20.0,
+			SnapshotBatchSize:     500,
+			SnapshotFlushInterval: Duration{time.Second},
+		},
+		Performance: PerformanceConfig{
+			TradeStatsWindowSize: 100,
+			TradeStatsMinSample:  10,
+		},
+		Backtest: BacktestConfig{
+			MakerFeeRate:        0.0,
+			TakerFeeRate:        0.01,
+			MinLiquidityForFill: 20.0,
+			MaxSlippagePct:      0.15,
 		},
 	}
 }
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MarketRow is one row for BulkInsertMarkets, mirroring the columns
+// Collector.upsertMarket writes one row at a time.
+type MarketRow struct {
+	ID          string
+	Question    string
+	OutcomeType string
+	Mechanism   string
+	CreatorID   string
+	CreatedTime int64
+	CloseTime   int64
+	URL         string
+	IsResolved  bool
+	Resolution  *string
+}
+
+// BulkInsertMarkets upserts rows in a single transaction over one prepared
+// statement, for loading a large batch of markets (e.g. a backtest data
+// import) without paying SQLite's per-Exec transaction overhead per row.
+func BulkInsertMarkets(db *sql.DB, rows []MarketRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url, is_resolved, resolution)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			is_resolved = excluded.is_resolved,
+			resolution = excluded.resolution,
+			last_updated_at = datetime('now')`)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		resolved := 0
+		if r.IsResolved {
+			resolved = 1
+		}
+		if _, err := stmt.Exec(r.ID, r.Question, r.OutcomeType, r.Mechanism, r.CreatorID, r.CreatedTime, r.CloseTime, r.URL, resolved, r.Resolution); err != nil {
+			return fmt.Errorf("inserting market %s: %w", r.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// BotBetRow is one row for BulkInsertBets, mirroring the columns
+// backtest.Runner.recordBacktestBet writes one row at a time.
+type BotBetRow struct {
+	MarketID        string
+	Strategy        string
+	Outcome         string
+	Amount          float64
+	ExpectedProb    float64
+	MarketProbAtBet float64
+	AvgFillProb     sql.NullFloat64
+	KellyFraction   float64
+	LayerIndex      int
+	PlacedAt        string
+}
+
+// BulkInsertBets inserts rows in a single transaction over one prepared
+// statement, for loading a large batch of bets (e.g. replaying a backtest
+// run's results) without paying SQLite's per-Exec transaction overhead per
+// row.
+func BulkInsertBets(db *sql.DB, rows []BotBetRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, avg_fill_prob, kelly_fraction, layer_index, placed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.MarketID, r.Strategy, r.Outcome, r.Amount, r.ExpectedProb, r.MarketProbAtBet, r.AvgFillProb, r.KellyFraction, r.LayerIndex, r.PlacedAt); err != nil {
+			return fmt.Errorf("inserting bet for market %s: %w", r.MarketID, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -5,48 +5,150 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	_ "modernc.org/sqlite"
+	"strings"
 )
 
-// Open creates or opens a SQLite database at the given path with WAL mode enabled.
+// defaultMMapSize is PRAGMA mmap_size when OpenOptions.MMapSize is unset:
+// the bot writes market_snapshots and active_orders at high frequency, and
+// memory-mapped I/O cuts the read-side syscall overhead of a reader tailing
+// them.
+const defaultMMapSize = 256 * 1024 * 1024
+
+// OpenOptions configures how Open connects to and tunes a SQLite database.
+type OpenOptions struct {
+	Path string
+
+	// ReadOnly opens a dedicated read-only handle with several connections
+	// allowed, for a reader tailing market_snapshots/active_orders
+	// concurrently with the writer handle (which Open always limits to a
+	// single connection, serializing writes under WAL).
+	ReadOnly bool
+
+	// MMapSize is PRAGMA mmap_size in bytes. 0 uses defaultMMapSize.
+	MMapSize int64
+
+	// JournalMode overrides PRAGMA journal_mode. Empty defaults to WAL,
+	// except for in-memory databases, where WAL is a no-op and the
+	// SQLite-default DELETE mode is left alone.
+	JournalMode string
+
+	// KeyProvider, if set, encrypts the database at rest via SQLCipher.
+	// Only honored when hyperkaehler is built with -tags sqlcipher; see
+	// sqlcipher_enabled.go and sqlcipher_disabled.go. bot_bets and
+	// bankroll_snapshots carry trading history and API-linked user IDs, so
+	// a deployment on a shared host may want this rather than plaintext.
+	KeyProvider KeyProvider
+
+	// CipherPageSize, KDFIter, and CipherHMACAlgorithm tune SQLCipher's
+	// PRAGMA cipher_page_size, kdf_iter, and cipher_hmac_algorithm. Zero
+	// values use SQLCipher's own defaults. Only meaningful alongside
+	// KeyProvider under a sqlcipher build.
+	CipherPageSize      int
+	KDFIter             int
+	CipherHMACAlgorithm string
+}
+
+// Open creates or opens a SQLite database at dbPath with a production
+// PRAGMA profile (WAL, NORMAL synchronous, in-memory temp store, a
+// generous mmap, a busy timeout, and foreign keys on) and a single-
+// connection write handle. Equivalent to
+// OpenWithOptions(OpenOptions{Path: dbPath}).
 func Open(dbPath string) (*sql.DB, error) {
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("creating db directory: %w", err)
+	return OpenWithOptions(OpenOptions{Path: dbPath})
+}
+
+// OpenWithOptions is Open with the PRAGMA profile and connection pool shape
+// under the caller's control — e.g. a read-only handle with several
+// connections for a reader, alongside the single-connection writer handle
+// that serializes bot_bets/market_snapshots inserts. In-memory databases
+// (":memory:", or a shared-cache URI like "file::memory:?cache=shared")
+// skip WAL, which is a no-op against them, unless JournalMode is set
+// explicitly.
+func OpenWithOptions(opts OpenOptions) (*sql.DB, error) {
+	isMemory := isMemoryDSN(opts.Path)
+
+	if !isMemory {
+		dir := filepath.Dir(opts.Path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating db directory: %w", err)
+		}
+	}
+
+	dsn := opts.Path
+	if opts.ReadOnly && !isMemory {
+		dsn = "file:" + opts.Path + "?mode=ro"
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	database, err := sql.Open(sqlDriverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
 
-	// Enable WAL mode for better concurrent read performance.
-	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("setting WAL mode: %w", err)
+	if opts.ReadOnly {
+		database.SetMaxOpenConns(4)
+	} else {
+		// A single connection serializes every write through database/sql's
+		// own connection pool, which combined with WAL (readers never block
+		// the writer) is the standard way to avoid SQLITE_BUSY under
+		// concurrent access from the scheduler's periodic loops.
+		database.SetMaxOpenConns(1)
 	}
 
-	// Enable foreign keys.
-	if _, err := db.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("enabling foreign keys: %w", err)
+	pragmas := []string{
+		"PRAGMA temp_store=MEMORY",
+		fmt.Sprintf("PRAGMA mmap_size=%d", mmapSizeOrDefault(opts.MMapSize)),
+		"PRAGMA busy_timeout=5000",
 	}
 
-	return db, nil
-}
+	// journal_mode, synchronous, and foreign_keys all write to the database
+	// header or schema on first application. A mode=ro connection can't do
+	// that — SQLite rejects the attempt — so a read-only handle leaves them
+	// at whatever the writer handle (which always opens first) already set.
+	if !opts.ReadOnly {
+		journalMode := opts.JournalMode
+		if journalMode == "" {
+			journalMode = "WAL"
+			if isMemory {
+				journalMode = "DELETE"
+			}
+		}
+		pragmas = append(pragmas,
+			fmt.Sprintf("PRAGMA journal_mode=%s", journalMode),
+			"PRAGMA synchronous=NORMAL",
+			"PRAGMA foreign_keys=ON",
+		)
+	}
 
-// Migrate runs the schema creation SQL. Safe to call multiple times due to IF NOT EXISTS.
-func Migrate(db *sql.DB) error {
-	if _, err := db.Exec(schemaSQL); err != nil {
-		return fmt.Errorf("running migrations: %w", err)
+	for _, pragma := range pragmas {
+		if _, err := database.Exec(pragma); err != nil {
+			database.Close()
+			return nil, fmt.Errorf("applying %q: %w", pragma, err)
+		}
 	}
 
-	// Record schema version 1 if not already present.
-	_, err := db.Exec(`INSERT OR IGNORE INTO schema_version (version) VALUES (1)`)
-	if err != nil {
-		return fmt.Errorf("recording schema version: %w", err)
+	if err := applyEncryption(database, opts); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("applying encryption: %w", err)
 	}
 
-	return nil
+	return database, nil
+}
+
+func isMemoryDSN(path string) bool {
+	return path == ":memory:" || strings.Contains(path, "mode=memory") || strings.HasPrefix(path, "file::memory:")
+}
+
+func mmapSizeOrDefault(configured int64) int64 {
+	if configured > 0 {
+		return configured
+	}
+	return defaultMMapSize
+}
+
+// Migrate brings the database up to date, applying every migration in the
+// registry newer than the highest one already applied. Safe to call on
+// every startup. See migrator.go for the versioned up/down subsystem this
+// delegates to, and the `hyperkaehler migrate` CLI for rollback/status.
+func Migrate(db *sql.DB) error {
+	return MigrateTo(db, latestVersion())
 }
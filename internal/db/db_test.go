@@ -1,6 +1,8 @@
 package db
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -16,7 +18,7 @@ func TestMigrate_CreatesAllTables(t *testing.T) {
 	}
 
 	tables := []string{
-		"schema_version",
+		"schema_migrations",
 		"markets",
 		"market_snapshots",
 		"bot_bets",
@@ -53,6 +55,144 @@ func TestMigrate_Idempotent(t *testing.T) {
 	}
 }
 
+func TestMigrate_RecordsEachVersion(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := database.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) {
+		t.Errorf("expected %d schema_migrations rows, got %d", len(migrations), count)
+	}
+
+	var maxVersion int
+	if err := database.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&maxVersion); err != nil {
+		t.Fatal(err)
+	}
+	if maxVersion != migrations[len(migrations)-1].Version {
+		t.Errorf("expected max version %d, got %d", migrations[len(migrations)-1].Version, maxVersion)
+	}
+
+	// A second run must not re-apply any migration (e.g. double-running the
+	// ALTER TABLE ADD COLUMN in migration 2 would error outright).
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) {
+		t.Errorf("expected schema_migrations row count to stay at %d after a second Migrate, got %d", len(migrations), count)
+	}
+}
+
+func TestMigrate_CreatesBotBetState(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	row := database.QueryRow(
+		`SELECT count(*) FROM sqlite_master WHERE type='table' AND name='bot_bet_state'`)
+	if err := row.Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Error("expected bot_bet_state table to exist")
+	}
+}
+
+func TestOpenWithOptions_AppliesPragmaProfile(t *testing.T) {
+	// mmap_size is a no-op against an in-memory database — it never
+	// populates PRAGMA mmap_size's result row — so assert it against a
+	// file-backed database, the same way TestOpenWithOptions_SkipsWALForInMemoryDB
+	// scopes its WAL assertion to the in-memory case it actually applies to.
+	path := filepath.Join(t.TempDir(), "pragma.db")
+	database, err := OpenWithOptions(OpenOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	var synchronous int
+	if err := database.QueryRow(`PRAGMA synchronous`).Scan(&synchronous); err != nil {
+		t.Fatal(err)
+	}
+	if synchronous != 1 { // NORMAL
+		t.Errorf("expected synchronous=NORMAL (1), got %d", synchronous)
+	}
+
+	var mmapSize int64
+	if err := database.QueryRow(`PRAGMA mmap_size`).Scan(&mmapSize); err != nil {
+		t.Fatal(err)
+	}
+	if mmapSize != defaultMMapSize {
+		t.Errorf("expected default mmap_size %d, got %d", defaultMMapSize, mmapSize)
+	}
+}
+
+func TestOpenWithOptions_SkipsWALForInMemoryDB(t *testing.T) {
+	database, err := OpenWithOptions(OpenOptions{Path: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	var mode string
+	if err := database.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if strings.EqualFold(mode, "wal") {
+		t.Error("expected WAL to be skipped for an in-memory database")
+	}
+}
+
+func TestOpenWithOptions_ReadOnlySkipsWritePragmas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "readonly.db")
+	writer, err := OpenWithOptions(OpenOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if err := Migrate(writer); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenWithOptions(OpenOptions{Path: path, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var count int
+	if err := reader.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='markets'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatal("expected the read-only handle to see the writer's schema")
+	}
+
+	if _, err := reader.Exec(`INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES ('m1', 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')`); err == nil {
+		t.Error("expected a write through the read-only handle to fail")
+	}
+}
+
 func TestMigrate_InsertAndQuery(t *testing.T) {
 	database, err := Open(":memory:")
 	if err != nil {
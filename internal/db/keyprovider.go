@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider supplies the raw key or passphrase used to encrypt a
+// SQLCipher database (see OpenOptions.KeyProvider). Kept independent of any
+// build tag so callers can construct and pass one around regardless of
+// whether the running binary was built with -tags sqlcipher; it's only
+// actually read under that build.
+type KeyProvider interface {
+	Key() (string, error)
+}
+
+// EnvKeyProvider reads the key from an environment variable. This is the
+// simplest option and the one most deployments should reach for first.
+type EnvKeyProvider struct {
+	EnvVar string
+}
+
+func (p EnvKeyProvider) Key() (string, error) {
+	key := os.Getenv(p.EnvVar)
+	if key == "" {
+		return "", fmt.Errorf("environment variable %s is unset or empty", p.EnvVar)
+	}
+	return key, nil
+}
+
+// FileKeyProvider reads the key from a file, trimming a single trailing
+// newline if present (so `echo mykey > keyfile` works as expected). Suited
+// to deployments where the key is mounted from a secrets manager.
+type FileKeyProvider struct {
+	Path string
+}
+
+func (p FileKeyProvider) Key() (string, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading key file %s: %w", p.Path, err)
+	}
+	key := strings.TrimSuffix(string(data), "\n")
+	key = strings.TrimSuffix(key, "\r")
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", p.Path)
+	}
+	return key, nil
+}
+
+// An OS-keychain KeyProvider is intentionally not included: it's
+// inherently platform-specific (Keychain on macOS, Secret Service on
+// Linux, Credential Manager on Windows) and hyperkaehler has never had a
+// per-OS build path for anything else. EnvKeyProvider or FileKeyProvider
+// cover the hosts this bot actually runs on; add one if a concrete
+// deployment needs it.
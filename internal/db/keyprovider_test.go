@@ -0,0 +1,62 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvKeyProvider_ReadsKey(t *testing.T) {
+	t.Setenv("HK_TEST_DB_KEY", "s3cr3t")
+	p := EnvKeyProvider{EnvVar: "HK_TEST_DB_KEY"}
+	key, err := p.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "s3cr3t" {
+		t.Errorf("expected key %q, got %q", "s3cr3t", key)
+	}
+}
+
+func TestEnvKeyProvider_ErrorsWhenUnset(t *testing.T) {
+	p := EnvKeyProvider{EnvVar: "HK_TEST_DB_KEY_UNSET"}
+	if _, err := p.Key(); err == nil {
+		t.Error("expected an error for an unset environment variable")
+	}
+}
+
+func TestFileKeyProvider_ReadsKeyTrimmingTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dbkey")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileKeyProvider{Path: path}
+	key, err := p.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "s3cr3t" {
+		t.Errorf("expected key %q, got %q", "s3cr3t", key)
+	}
+}
+
+func TestFileKeyProvider_ErrorsOnEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dbkey")
+	if err := os.WriteFile(path, []byte(""), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileKeyProvider{Path: path}
+	if _, err := p.Key(); err == nil {
+		t.Error("expected an error for an empty key file")
+	}
+}
+
+func TestOpenWithOptions_RejectsKeyProviderWithoutSqlcipherBuild(t *testing.T) {
+	_, err := OpenWithOptions(OpenOptions{
+		Path:        ":memory:",
+		KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_DB_KEY_UNSET"},
+	})
+	if err == nil {
+		t.Error("expected an error when KeyProvider is set without a sqlcipher build")
+	}
+}
@@ -1,11 +1,32 @@
 package db
 
-const schemaSQL = `
-CREATE TABLE IF NOT EXISTS schema_version (
-    version INTEGER PRIMARY KEY,
-    applied_at TEXT NOT NULL DEFAULT (datetime('now'))
-);
+// migration is one versioned, ordered schema change. Up runs inside its own
+// transaction; Migrate applies migrations in Version order, skipping any
+// with Version <= the highest version already recorded in schema_migrations.
+// Down reverses Up for Rollback — it must be the exact inverse, since a
+// migration already applied in a user's DB must stay byte-for-byte whatever
+// it was when it ran (the checksum recorded at apply time enforces this).
+type migration struct {
+	Version     int
+	Description string
+	Up          string
+	Down        string
+}
+
+// migrations is the ordered registry of every schema change this bot has
+// ever shipped. Append new entries with increasing Version; never edit or
+// reorder an existing one — a migration already applied in a user's DB must
+// stay byte-for-byte whatever it was when it ran.
+var migrations = []migration{
+	{1, "baseline schema", baselineSchemaSQL, baselineSchemaDownSQL},
+	{2, "add layer_index to bot_bets and an index on (market_id, resolved)", addLayerIndexSQL, addLayerIndexDownSQL},
+	{3, "add bot_bet_state for trailing exits", addBotBetStateSQL, addBotBetStateDownSQL},
+	{4, "add answer_id to active_orders and filled_amount/status to bot_bets", addOrderTrackingSQL, addOrderTrackingDownSQL},
+	{5, "add avg_fill_prob to bot_bets", addAvgFillProbSQL, addAvgFillProbDownSQL},
+	{6, "add risk_state_history for drawdown audit trail", addRiskStateHistorySQL, addRiskStateHistoryDownSQL},
+}
 
+const baselineSchemaSQL = `
 CREATE TABLE IF NOT EXISTS markets (
     id TEXT PRIMARY KEY,
     question TEXT NOT NULL,
@@ -49,7 +70,8 @@ CREATE TABLE IF NOT EXISTS bot_bets (
     resolved INTEGER NOT NULL DEFAULT 0,
     resolution TEXT,
     pnl REAL,
-    resolved_at TEXT
+    resolved_at TEXT,
+    manifold_bet_id TEXT UNIQUE
 );
 CREATE INDEX IF NOT EXISTS idx_bets_strategy ON bot_bets(strategy);
 CREATE INDEX IF NOT EXISTS idx_bets_market ON bot_bets(market_id);
@@ -76,4 +98,151 @@ CREATE TABLE IF NOT EXISTS active_orders (
     is_active INTEGER NOT NULL DEFAULT 1
 );
 CREATE INDEX IF NOT EXISTS idx_active_orders_active ON active_orders(is_active) WHERE is_active = 1;
+
+CREATE TABLE IF NOT EXISTS risk_high_water_mark (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    peak_balance REAL NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS position_peaks (
+    market_id TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    peak_move REAL NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (market_id, outcome)
+);
+
+CREATE TABLE IF NOT EXISTS circuit_breaker_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reason TEXT NOT NULL,
+    tripped_at TEXT NOT NULL DEFAULT (datetime('now')),
+    cool_off_seconds INTEGER NOT NULL,
+    reset_at TEXT,
+    strategy TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS daily_usage (
+    usage_date TEXT PRIMARY KEY,
+    mana_spent REAL NOT NULL DEFAULT 0,
+    bets_count INTEGER NOT NULL DEFAULT 0,
+    volume REAL NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS decay_calibration (
+    decile INTEGER PRIMARY KEY,
+    sample_size INTEGER NOT NULL,
+    decay_factor REAL NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS strategy_stats_snapshots (
+    strategy TEXT PRIMARY KEY,
+    sample_size INTEGER NOT NULL,
+    sharpe REAL NOT NULL,
+    sortino REAL NOT NULL,
+    profit_factor REAL NOT NULL,
+    avg_claimed_edge REAL NOT NULL,
+    avg_realized_edge REAL NOT NULL,
+    current_win_streak INTEGER NOT NULL,
+    current_loss_streak INTEGER NOT NULL,
+    max_win_streak INTEGER NOT NULL,
+    max_loss_streak INTEGER NOT NULL,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+`
+
+// baselineSchemaDownSQL drops every table the baseline schema created, in
+// an order that respects their REFERENCES. Rolling back past version 1
+// discards all bot history — Rollback never runs this implicitly, only on
+// an explicit operator request.
+const baselineSchemaDownSQL = `
+DROP TABLE IF EXISTS strategy_stats_snapshots;
+DROP TABLE IF EXISTS decay_calibration;
+DROP TABLE IF EXISTS daily_usage;
+DROP TABLE IF EXISTS circuit_breaker_events;
+DROP TABLE IF EXISTS position_peaks;
+DROP TABLE IF EXISTS risk_high_water_mark;
+DROP TABLE IF EXISTS active_orders;
+DROP TABLE IF EXISTS bankroll_snapshots;
+DROP TABLE IF EXISTS bot_bets;
+DROP TABLE IF EXISTS market_snapshots;
+DROP TABLE IF EXISTS markets;
+`
+
+const addLayerIndexSQL = `
+ALTER TABLE bot_bets ADD COLUMN layer_index INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_bets_market_resolved ON bot_bets(market_id, resolved);
+`
+
+const addLayerIndexDownSQL = `
+DROP INDEX IF EXISTS idx_bets_market_resolved;
+ALTER TABLE bot_bets DROP COLUMN layer_index;
+`
+
+// addBotBetStateSQL adds per-bet exit-tracking state, keyed by bot_bets.id
+// rather than (market_id, outcome) like position_peaks: a future trailing
+// exit that wants per-fill (rather than per-position) granularity can track
+// its own peak ROI here without disturbing position_peaks' existing
+// consumers.
+const addBotBetStateSQL = `
+CREATE TABLE IF NOT EXISTS bot_bet_state (
+    bet_id INTEGER PRIMARY KEY REFERENCES bot_bets(id),
+    peak_roi REAL NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+`
+
+const addBotBetStateDownSQL = `
+DROP TABLE IF EXISTS bot_bet_state;
+`
+
+// addOrderTrackingSQL lets execution.ActiveOrderBook key a resting limit
+// order by (market_id, answer_id, outcome, limit_prob) and record what
+// Reconcile observes about its fill state. Existing rows default to
+// status 'filled' since every bet recorded before this migration was
+// either a market order (filled immediately) or a limit order the bot
+// never tracked the fill state of.
+const addOrderTrackingSQL = `
+ALTER TABLE active_orders ADD COLUMN answer_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE bot_bets ADD COLUMN filled_amount REAL NOT NULL DEFAULT 0;
+ALTER TABLE bot_bets ADD COLUMN status TEXT NOT NULL DEFAULT 'filled';
+`
+
+const addOrderTrackingDownSQL = `
+ALTER TABLE bot_bets DROP COLUMN status;
+ALTER TABLE bot_bets DROP COLUMN filled_amount;
+ALTER TABLE active_orders DROP COLUMN answer_id;
+`
+
+// addAvgFillProbSQL records the average execution price backtest.Runner's
+// CPMM fill simulation produced for a bet, alongside the pre-trade
+// market_prob_at_bet already recorded — the gap between the two is the
+// simulated slippage plus fees for that trade. NULL for live bets and for
+// backtest bets whose FillModel never filled them.
+const addAvgFillProbSQL = `
+ALTER TABLE bot_bets ADD COLUMN avg_fill_prob REAL;
+`
+
+const addAvgFillProbDownSQL = `
+ALTER TABLE bot_bets DROP COLUMN avg_fill_prob;
+`
+
+// addRiskStateHistorySQL gives risk.Manager an append-only audit trail of
+// the peak balance and exposure it saw each time it refreshed, independent
+// of risk_high_water_mark (which only keeps the current value) — so an
+// operator can chart the drawdown timeline rather than just the latest
+// number.
+const addRiskStateHistorySQL = `
+CREATE TABLE IF NOT EXISTS risk_state_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    peak_balance REAL NOT NULL,
+    total_exposure REAL NOT NULL,
+    total_value REAL NOT NULL,
+    recorded_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+`
+
+const addRiskStateHistoryDownSQL = `
+DROP TABLE IF EXISTS risk_state_history;
 `
@@ -0,0 +1,292 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// MigrateTo applies every migration in the registry newer than the highest
+// one already applied, stopping at (and including) target rather than
+// running through the latest registered migration. Each migration runs
+// inside its own transaction with its applied version recorded alongside
+// it, so a crash mid-migration never leaves schema_migrations out of sync
+// with the schema it describes.
+func MigrateTo(db *sql.DB, target int) error {
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return err
+	}
+	if err := verifyChecksums(db); err != nil {
+		return err
+	}
+
+	current, err := currentSchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("applying migration %d (%s): %w", m.Version, m.Description, err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback undoes the most recently applied `steps` migrations, in reverse
+// order, by running each one's Down SQL inside its own transaction and
+// removing its schema_migrations row. It refuses to roll back a migration
+// that was registered without a Down script rather than leaving the
+// schema half-reversed.
+func Rollback(db *sql.DB, steps int) error {
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return err
+	}
+
+	applied, err := appliedVersionsDesc(db)
+	if err != nil {
+		return fmt.Errorf("reading applied migrations: %w", err)
+	}
+	if steps > len(applied) {
+		steps = len(applied)
+	}
+
+	for i := 0; i < steps; i++ {
+		m, ok := migrationByVersion(applied[i])
+		if !ok {
+			return fmt.Errorf("rollback: migration %d is applied but no longer in the registry", applied[i])
+		}
+		if m.Down == "" {
+			return fmt.Errorf("rollback: migration %d (%s) has no Down script", m.Version, m.Description)
+		}
+		if err := applyRollback(db, m); err != nil {
+			return fmt.Errorf("rolling back migration %d (%s): %w", m.Version, m.Description, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrationStatus describes one registered migration's applied state, for
+// the `hyperkaehler migrate status` subcommand.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   string
+}
+
+// Status reports every migration in the registry alongside whether (and
+// when) it's been applied to db.
+func Status(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[int]string)
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, applied := appliedAt[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:     m.Version,
+			Description: m.Description,
+			Applied:     applied,
+			AppliedAt:   at,
+		})
+	}
+	return statuses, nil
+}
+
+func latestVersion() int {
+	var max int
+	for _, m := range migrations {
+		if m.Version > max {
+			max = m.Version
+		}
+	}
+	return max
+}
+
+func migrationByVersion(version int) (migration, bool) {
+	for _, m := range migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// checksum returns a stable hex digest of a migration's Up+Down SQL. It's
+// recorded alongside the applied version so a later edit to an
+// already-applied migration's body — which would silently desync a user's
+// actual schema from what the registry claims it is — gets caught instead
+// of ignored.
+func checksum(m migration) string {
+	h := sha256.Sum256([]byte(m.Up + "\x00" + m.Down))
+	return hex.EncodeToString(h[:])
+}
+
+func ensureSchemaMigrationsTable(db *sql.DB) error {
+	if _, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    description TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TEXT NOT NULL DEFAULT (datetime('now'))
+);`); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+	return backfillLegacySchemaVersion(db)
+}
+
+// backfillLegacySchemaVersion copies rows from the bare schema_version
+// table earlier releases used (just a version number, no description or
+// checksum) into schema_migrations the first time it's seen, so an
+// existing install doesn't try to re-run migrations it already has.
+func backfillLegacySchemaVersion(db *sql.DB) error {
+	var legacyExists int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`,
+	).Scan(&legacyExists); err != nil {
+		return fmt.Errorf("checking for legacy schema_version table: %w", err)
+	}
+	if legacyExists == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(`SELECT version FROM schema_version`)
+	if err != nil {
+		return fmt.Errorf("reading legacy schema_version: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		m, ok := migrationByVersion(version)
+		if !ok {
+			continue
+		}
+		if _, err := db.Exec(`
+			INSERT OR IGNORE INTO schema_migrations (version, description, checksum)
+			VALUES (?, ?, ?)`,
+			m.Version, m.Description, checksum(m),
+		); err != nil {
+			return fmt.Errorf("backfilling schema_migrations from legacy table: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+func verifyChecksums(db *sql.DB) error {
+	rows, err := db.Query(`SELECT version, checksum FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("reading applied checksums: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var stored string
+		if err := rows.Scan(&version, &stored); err != nil {
+			return err
+		}
+		m, ok := migrationByVersion(version)
+		if !ok {
+			// Applied historically by a build whose registry we no longer
+			// have; nothing to compare against.
+			continue
+		}
+		if want := checksum(m); want != stored {
+			return fmt.Errorf("migration %d (%s) has changed since it was applied — checksum drift detected", m.Version, m.Description)
+		}
+	}
+	return rows.Err()
+}
+
+func currentSchemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func appliedVersionsDesc(db *sql.DB) ([]int, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.Up); err != nil {
+		return fmt.Errorf("running up SQL: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO schema_migrations (version, description, checksum) VALUES (?, ?, ?)`,
+		m.Version, m.Description, checksum(m),
+	); err != nil {
+		return fmt.Errorf("recording version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func applyRollback(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.Down); err != nil {
+		return fmt.Errorf("running down SQL: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+		return fmt.Errorf("removing version record: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package db
+
+import "testing"
+
+func TestStatus_ReportsAppliedAndPending(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := MigrateTo(database, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := Status(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("expected %d statuses, got %d", len(migrations), len(statuses))
+	}
+	for _, s := range statuses {
+		wantApplied := s.Version <= 2
+		if s.Applied != wantApplied {
+			t.Errorf("migration %d: expected applied=%v, got %v", s.Version, wantApplied, s.Applied)
+		}
+	}
+}
+
+func TestRollback_ReversesMostRecentMigrations(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rollback(database, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := currentSchemaVersion(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantVersion := migrations[len(migrations)-2].Version
+	if current != wantVersion {
+		t.Errorf("expected schema version %d after rolling back one step, got %d", wantVersion, current)
+	}
+
+	// The rolled-back migration's table should be gone.
+	var count int
+	if err := database.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='risk_state_history'`,
+	).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Error("expected risk_state_history to be dropped by rollback")
+	}
+
+	// Migrating forward again should reapply it cleanly.
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrate_RejectsChecksumDrift(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an already-applied migration's body having changed since it
+	// ran, by corrupting its recorded checksum.
+	if _, err := database.Exec(
+		`UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(database); err == nil {
+		t.Error("expected Migrate to reject a database with checksum drift")
+	}
+}
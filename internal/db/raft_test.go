@@ -0,0 +1,214 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newRaftCluster wires up len(ids) file-backed RaftStore nodes (file-backed
+// so Backup/Restore tests have something to VACUUM INTO and replace) over a
+// shared InMemTransport. electionTimeouts is keyed by id and should assign
+// distinct values so tests get a deterministic first leader instead of
+// relying on randomized timing.
+func newRaftCluster(t *testing.T, ids []string, electionTimeouts map[string]int) (map[string]*RaftStore, *InMemTransport) {
+	t.Helper()
+	transport := NewInMemTransport()
+	nodes := make(map[string]*RaftStore, len(ids))
+	dir := t.TempDir()
+
+	for _, id := range ids {
+		var peers []string
+		for _, other := range ids {
+			if other != id {
+				peers = append(peers, other)
+			}
+		}
+
+		path := filepath.Join(dir, id+".db")
+		database, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Migrate(database); err != nil {
+			t.Fatal(err)
+		}
+
+		node := NewRaftStore(id, peers, database, path, transport, electionTimeouts[id])
+		transport.Register(node)
+		nodes[id] = node
+	}
+
+	return nodes, transport
+}
+
+// advance ticks every node, in id order, for n rounds.
+func advance(nodes map[string]*RaftStore, ids []string, n int) {
+	for i := 0; i < n; i++ {
+		for _, id := range ids {
+			nodes[id].Tick()
+		}
+	}
+}
+
+func leaderOf(nodes map[string]*RaftStore) *RaftStore {
+	for _, node := range nodes {
+		if node.IsLeader() {
+			return node
+		}
+	}
+	return nil
+}
+
+const insertMarketM1 = `INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+	VALUES ('m1', 'Test?', 'BINARY', 'cpmm-1', 'user1', 1700000000000, 1800000000000, 'https://example.com')`
+
+func TestRaftCluster_ElectsExactlyOneLeader(t *testing.T) {
+	ids := []string{"n1", "n2", "n3"}
+	nodes, _ := newRaftCluster(t, ids, map[string]int{"n1": 3, "n2": 8, "n3": 11})
+
+	advance(nodes, ids, 5)
+
+	count := 0
+	for _, node := range nodes {
+		if node.IsLeader() {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected exactly 1 leader after the cluster settles, got %d", count)
+	}
+	if !nodes["n1"].IsLeader() {
+		t.Error("expected n1 (shortest election timeout) to win the first election")
+	}
+}
+
+func TestRaftCluster_ReplicatesExecuteToFollowers(t *testing.T) {
+	ids := []string{"n1", "n2", "n3"}
+	nodes, _ := newRaftCluster(t, ids, map[string]int{"n1": 3, "n2": 8, "n3": 11})
+	advance(nodes, ids, 5)
+
+	leader := leaderOf(nodes)
+	if leader == nil {
+		t.Fatal("expected a leader to be elected")
+	}
+	if _, err := leader.Execute([]Statement{{Query: insertMarketM1}}); err != nil {
+		t.Fatalf("leader Execute failed: %v", err)
+	}
+
+	// More heartbeat rounds so every follower learns the entry committed,
+	// including the one the leader replicated to before its own commitIndex
+	// advanced, which received the entry but not the commit.
+	advance(nodes, ids, 2)
+
+	for _, id := range ids {
+		rows, err := nodes[id].Query(Statement{Query: `SELECT COUNT(*) FROM markets WHERE id = 'm1'`}, Weak)
+		if err != nil {
+			t.Fatalf("query on %s failed: %v", id, err)
+		}
+		if !rows.Next() {
+			t.Fatalf("no row returned from %s", id)
+		}
+		var count int
+		if err := rows.Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+		if count != 1 {
+			t.Errorf("expected the market replicated to %s, got count %d", id, count)
+		}
+	}
+}
+
+func TestRaftCluster_StrongReadRefusedOnFollower(t *testing.T) {
+	ids := []string{"n1", "n2", "n3"}
+	nodes, _ := newRaftCluster(t, ids, map[string]int{"n1": 3, "n2": 8, "n3": 11})
+	advance(nodes, ids, 5)
+
+	for _, id := range ids {
+		node := nodes[id]
+		_, err := node.Query(Statement{Query: `SELECT 1`}, Strong)
+		if node.IsLeader() {
+			if err != nil {
+				t.Errorf("expected a Strong read on the leader to succeed, got %v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("expected a Strong read on follower %s to be refused", id)
+		}
+	}
+}
+
+func TestRaftCluster_FailsOverWhenLeaderPartitioned(t *testing.T) {
+	ids := []string{"n1", "n2", "n3"}
+	nodes, transport := newRaftCluster(t, ids, map[string]int{"n1": 3, "n2": 8, "n3": 11})
+	advance(nodes, ids, 5)
+
+	if !nodes["n1"].IsLeader() {
+		t.Fatal("expected n1 to be the initial leader")
+	}
+
+	// Partition the leader away from both followers, simulating it going
+	// dark mid-cluster rather than shutting down cleanly.
+	transport.Partition("n1", "n2")
+	transport.Partition("n1", "n3")
+
+	// n2 and n3 stop hearing n1's heartbeats; advance well past both of
+	// their election timeouts so one of them wins a new term.
+	advance(nodes, ids, 15)
+
+	var newLeader *RaftStore
+	for _, id := range []string{"n2", "n3"} {
+		if nodes[id].IsLeader() {
+			newLeader = nodes[id]
+		}
+	}
+	if newLeader == nil {
+		t.Fatal("expected n2 or n3 to take over as leader once n1 was partitioned away")
+	}
+
+	if _, err := newLeader.Execute([]Statement{{Query: insertMarketM1}}); err != nil {
+		t.Fatalf("expected the new leader to accept writes, got %v", err)
+	}
+}
+
+func TestRaftStore_BackupRestoreRoundTrip(t *testing.T) {
+	ids := []string{"n1", "n2", "n3"}
+	nodes, _ := newRaftCluster(t, ids, map[string]int{"n1": 3, "n2": 8, "n3": 11})
+	advance(nodes, ids, 5)
+
+	leader := leaderOf(nodes)
+	if leader == nil {
+		t.Fatal("expected a leader to be elected")
+	}
+	if _, err := leader.Execute([]Statement{{Query: insertMarketM1}}); err != nil {
+		t.Fatalf("leader Execute failed: %v", err)
+	}
+	advance(nodes, ids, 2)
+
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+	if err := leader.Backup(backupPath); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+
+	if _, err := leader.local.db.Exec(`DELETE FROM markets WHERE id = 'm1'`); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := leader.local.db.QueryRow(`SELECT COUNT(*) FROM markets WHERE id = 'm1'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected the market deleted before restore, got count %d", count)
+	}
+
+	if err := leader.Restore(backupPath); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if err := leader.local.db.QueryRow(`SELECT COUNT(*) FROM markets WHERE id = 'm1'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected the market restored from backup, got count %d", count)
+	}
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultSnapshotBatchSize and DefaultSnapshotFlushInterval are
+// SnapshotWriter's defaults when NewSnapshotWriter is given a zero value:
+// 500 rows, or whatever's accumulated after 1 second, whichever comes
+// first.
+const (
+	DefaultSnapshotBatchSize     = 500
+	DefaultSnapshotFlushInterval = time.Second
+)
+
+// Snapshot is one row destined for market_snapshots, mirroring the columns
+// Collector.snapshot writes one row at a time.
+type Snapshot struct {
+	MarketID       string
+	Probability    float64
+	AnswerProbs    *string
+	Volume         float64
+	Volume24h      float64
+	TotalLiquidity float64
+	PoolYes        *float64
+	PoolNo         *float64
+}
+
+// SnapshotWriter batches Snapshots into periodic transactions built on a
+// single prepared INSERT, instead of one Exec per row. Collector polls
+// every scanned market on every cycle, so market_snapshots grows by one
+// row per market per poll; at any real market count, per-row inserts
+// (each its own implicit transaction under SQLite) become the collection
+// loop's bottleneck well before the bot's other work does.
+type SnapshotWriter struct {
+	db            *sql.DB
+	batchSize     int
+	flushInterval time.Duration
+
+	in   chan Snapshot
+	done chan struct{}
+}
+
+// NewSnapshotWriter starts a background goroutine draining snapshots from
+// an internal channel into batched transactions. batchSize <= 0 uses
+// DefaultSnapshotBatchSize; flushInterval <= 0 uses
+// DefaultSnapshotFlushInterval. Call Close to flush any partial batch and
+// stop the goroutine.
+func NewSnapshotWriter(db *sql.DB, batchSize int, flushInterval time.Duration) *SnapshotWriter {
+	if batchSize <= 0 {
+		batchSize = DefaultSnapshotBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultSnapshotFlushInterval
+	}
+
+	w := &SnapshotWriter{
+		db:            db,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		in:            make(chan Snapshot, batchSize),
+		done:          make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write enqueues a snapshot. It blocks once the internal channel (sized to
+// batchSize) is full, applying backpressure to a caller that's producing
+// snapshots faster than they can be flushed rather than buffering them
+// without bound.
+func (w *SnapshotWriter) Write(s Snapshot) {
+	w.in <- s
+}
+
+// Close stops accepting new snapshots, flushes whatever's pending, and
+// waits for the writer goroutine to exit. Safe to call once.
+func (w *SnapshotWriter) Close() error {
+	close(w.in)
+	<-w.done
+	return nil
+}
+
+func (w *SnapshotWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Snapshot, 0, w.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.flush(batch); err != nil {
+			slog.Error("snapshot writer: flush failed, dropping batch", "rows", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s, ok := <-w.in:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush commits batch as a single transaction over one prepared INSERT. A
+// failure here (e.g. the database vanishing mid-crash) drops only this
+// batch: everything committed by a prior flush is already durable.
+func (w *SnapshotWriter) flush(batch []Snapshot) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO market_snapshots (market_id, probability, answer_probs, volume, volume_24h, total_liquidity, pool_yes, pool_no)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, s := range batch {
+		if _, err := stmt.Exec(s.MarketID, s.Probability, s.AnswerProbs, s.Volume, s.Volume24h, s.TotalLiquidity, s.PoolYes, s.PoolNo); err != nil {
+			return fmt.Errorf("inserting snapshot for market %s: %w", s.MarketID, err)
+		}
+	}
+
+	return tx.Commit()
+}
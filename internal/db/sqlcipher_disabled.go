@@ -0,0 +1,36 @@
+//go:build !sqlcipher
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqlDriverName is the database/sql driver registered for ordinary builds:
+// modernc.org/sqlite, a pure-Go driver with no SQLCipher support.
+const sqlDriverName = "sqlite"
+
+// applyEncryption refuses a configured KeyProvider rather than silently
+// opening a plaintext database: modernc.org/sqlite has no PRAGMA key to
+// apply it against, so honoring it here would produce a database that
+// looks encrypted from the config but isn't. Build with -tags sqlcipher
+// for real encryption at rest.
+func applyEncryption(database *sql.DB, opts OpenOptions) error {
+	if opts.KeyProvider == nil {
+		return nil
+	}
+	return fmt.Errorf("OpenOptions.KeyProvider requires building hyperkaehler with -tags sqlcipher")
+}
+
+// Rekey requires a sqlcipher build; see sqlcipher_enabled.go.
+func Rekey(database *sql.DB, newKey string) error {
+	return fmt.Errorf("Rekey requires building hyperkaehler with -tags sqlcipher")
+}
+
+// MigrateToEncrypted requires a sqlcipher build; see sqlcipher_enabled.go.
+func MigrateToEncrypted(plainPath, encPath string, keyProvider KeyProvider) error {
+	return fmt.Errorf("MigrateToEncrypted requires building hyperkaehler with -tags sqlcipher")
+}
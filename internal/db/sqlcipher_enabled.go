@@ -0,0 +1,105 @@
+//go:build sqlcipher
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mutecomm/go-sqlcipher/v4"
+)
+
+// sqlDriverName is the database/sql driver registered under a sqlcipher
+// build: a cgo driver linked against libsqlcipher, the only way to get
+// PRAGMA key/cipher_page_size/kdf_iter support.
+const sqlDriverName = "sqlite3"
+
+const (
+	defaultCipherPageSize      = 4096
+	defaultKDFIter             = 256000
+	defaultCipherHMACAlgorithm = "HMAC_SHA512"
+)
+
+// applyEncryption issues PRAGMA key and the cipher tuning pragmas
+// immediately after open, then probes sqlite_master to fail fast on a
+// wrong key rather than surfacing a cryptic error from the first real
+// query a caller happens to run (SQLCipher validates the key lazily, on
+// first page read).
+func applyEncryption(database *sql.DB, opts OpenOptions) error {
+	if opts.KeyProvider == nil {
+		return nil
+	}
+	key, err := opts.KeyProvider.Key()
+	if err != nil {
+		return fmt.Errorf("reading encryption key: %w", err)
+	}
+
+	pageSize := opts.CipherPageSize
+	if pageSize == 0 {
+		pageSize = defaultCipherPageSize
+	}
+	kdfIter := opts.KDFIter
+	if kdfIter == 0 {
+		kdfIter = defaultKDFIter
+	}
+	hmacAlgorithm := opts.CipherHMACAlgorithm
+	if hmacAlgorithm == "" {
+		hmacAlgorithm = defaultCipherHMACAlgorithm
+	}
+
+	pragmas := []string{
+		fmt.Sprintf("PRAGMA key = %q", key),
+		fmt.Sprintf("PRAGMA cipher_page_size = %d", pageSize),
+		fmt.Sprintf("PRAGMA kdf_iter = %d", kdfIter),
+		fmt.Sprintf("PRAGMA cipher_hmac_algorithm = %s", hmacAlgorithm),
+	}
+	for _, pragma := range pragmas {
+		if _, err := database.Exec(pragma); err != nil {
+			return fmt.Errorf("applying %q: %w", pragma, err)
+		}
+	}
+
+	if _, err := database.Exec(`SELECT count(*) FROM sqlite_master`); err != nil {
+		return fmt.Errorf("verifying encryption key: %w", err)
+	}
+
+	return nil
+}
+
+// Rekey re-encrypts database with newKey, replacing whatever key (if any)
+// it's currently opened with.
+func Rekey(database *sql.DB, newKey string) error {
+	if _, err := database.Exec(fmt.Sprintf("PRAGMA rekey = %q", newKey)); err != nil {
+		return fmt.Errorf("rekeying database: %w", err)
+	}
+	return nil
+}
+
+// MigrateToEncrypted copies the plaintext database at plainPath into a new
+// SQLCipher-encrypted database at encPath via sqlcipher_export, leaving
+// plainPath untouched so the caller can verify the encrypted copy before
+// removing the original.
+func MigrateToEncrypted(plainPath, encPath string, keyProvider KeyProvider) error {
+	key, err := keyProvider.Key()
+	if err != nil {
+		return fmt.Errorf("reading encryption key: %w", err)
+	}
+
+	database, err := sql.Open(sqlDriverName, plainPath)
+	if err != nil {
+		return fmt.Errorf("opening plaintext database: %w", err)
+	}
+	defer database.Close()
+
+	stmts := []string{
+		fmt.Sprintf("ATTACH DATABASE %q AS enc KEY %q", encPath, key),
+		"SELECT sqlcipher_export('enc')",
+		"DETACH DATABASE enc",
+	}
+	for _, stmt := range stmts {
+		if _, err := database.Exec(stmt); err != nil {
+			return fmt.Errorf("executing %q: %w", stmt, err)
+		}
+	}
+	return nil
+}
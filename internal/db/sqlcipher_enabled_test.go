@@ -0,0 +1,80 @@
+//go:build sqlcipher
+
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests in this file only build and run under -tags sqlcipher, against a
+// real libsqlcipher. They use KDFIter: 1 rather than the production
+// default of 256000 so the suite doesn't pay SQLCipher's KDF cost on every
+// run, mirroring how other projects keep their sqlcipher tests fast.
+
+func TestOpenWithOptions_EncryptsAndReopensWithKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	opts := OpenOptions{Path: path, KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_SQLCIPHER_KEY"}, KDFIter: 1}
+	os.Setenv("HK_TEST_SQLCIPHER_KEY", "correct-key")
+
+	database, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	database.Close()
+
+	reopened, err := OpenWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	var count int
+	if err := reopened.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='markets'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Error("expected the markets table to survive a close/reopen with the same key")
+	}
+}
+
+func TestOpenWithOptions_RejectsWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	os.Setenv("HK_TEST_SQLCIPHER_KEY", "correct-key")
+	database, err := OpenWithOptions(OpenOptions{Path: path, KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_SQLCIPHER_KEY"}, KDFIter: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	database.Close()
+
+	os.Setenv("HK_TEST_SQLCIPHER_KEY", "wrong-key")
+	if _, err := OpenWithOptions(OpenOptions{Path: path, KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_SQLCIPHER_KEY"}, KDFIter: 1}); err == nil {
+		t.Error("expected opening with the wrong key to fail verification")
+	}
+}
+
+func TestRekey_AllowsReopenWithNewKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	os.Setenv("HK_TEST_SQLCIPHER_KEY", "old-key")
+	database, err := OpenWithOptions(OpenOptions{Path: path, KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_SQLCIPHER_KEY"}, KDFIter: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Rekey(database, "new-key"); err != nil {
+		t.Fatal(err)
+	}
+	database.Close()
+
+	os.Setenv("HK_TEST_SQLCIPHER_KEY", "new-key")
+	reopened, err := OpenWithOptions(OpenOptions{Path: path, KeyProvider: EnvKeyProvider{EnvVar: "HK_TEST_SQLCIPHER_KEY"}, KDFIter: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ConsistencyLevel controls how a Query is allowed to be served relative to
+// the most recently committed write. LocalStore serves every level
+// identically — there is only one copy of the data, so every read already
+// observes every prior write. RaftStore (see raft.go) serves None and Weak
+// reads from the local copy on any node and refuses Strong reads on a
+// follower, so callers can express the consistency they actually need
+// (e.g. a Strong read before sizing a bet).
+type ConsistencyLevel int
+
+const (
+	// None makes no consistency guarantee; fine for advisory/reporting reads.
+	None ConsistencyLevel = iota
+	// Weak tolerates reading slightly stale data.
+	Weak
+	// Strong requires reading the most recently committed write.
+	Strong
+)
+
+// Statement is a single parameterized SQL statement, serializable enough to
+// travel as a replicated log entry in RaftStore.
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// Result is the outcome of executing one Statement, mirroring the subset of
+// sql.Result that's meaningful to report back to a caller across a Store
+// boundary.
+type Result struct {
+	LastInsertID int64
+	RowsAffected int64
+}
+
+// Store is the interface the rest of the bot (scheduler, execution,
+// performance) could use instead of a raw *sql.DB, so that a replicated
+// backend is a drop-in swap rather than a rewrite of every caller.
+// LocalStore is today's deployment shape; RaftStore (see raft.go) is a
+// second implementation for running multiple hyperkaehler instances
+// against one logical database with hot leader failover.
+//
+// RaftStore implements the Raft consensus algorithm by hand rather than
+// vendoring Hashicorp's raft: hyperkaehler has no other external
+// consensus/networking dependency, and the cluster sizes this bot would
+// ever run (a handful of instances sharing one bankroll) don't need
+// hashicorp/raft's production hardening (pipelined replication, log
+// compaction over a streaming snapshot, etc.) to get real leader election,
+// replication, and failover. If multi-region or much larger clusters
+// become a real requirement, that's the point to reconsider vendoring it.
+type Store interface {
+	// Execute applies each Statement in order inside a single transaction,
+	// returning one Result per statement. If any statement fails, the whole
+	// batch is rolled back.
+	Execute(stmts []Statement) ([]Result, error)
+	// Query runs a single read-only Statement at the given ConsistencyLevel
+	// and returns the resulting rows.
+	Query(stmt Statement, level ConsistencyLevel) (*sql.Rows, error)
+	// IsLeader reports whether this Store may accept writes. LocalStore is
+	// always its own leader, since it's the only copy of the data.
+	IsLeader() bool
+}
+
+// LocalStore is a Store backed directly by a *sql.DB opened via Open or
+// OpenWithOptions — today's only deployment shape.
+type LocalStore struct {
+	db *sql.DB
+}
+
+// NewLocalStore wraps an already-open database handle as a Store.
+func NewLocalStore(db *sql.DB) *LocalStore {
+	return &LocalStore{db: db}
+}
+
+func (s *LocalStore) Execute(stmts []Statement) ([]Result, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]Result, len(stmts))
+	for i, stmt := range stmts {
+		res, err := tx.Exec(stmt.Query, stmt.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("executing statement %d: %w", i, err)
+		}
+		lastID, _ := res.LastInsertId()
+		rowsAffected, _ := res.RowsAffected()
+		results[i] = Result{LastInsertID: lastID, RowsAffected: rowsAffected}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+	return results, nil
+}
+
+// Query ignores level: a single local SQLite file has no replication lag
+// for any level to guard against.
+func (s *LocalStore) Query(stmt Statement, level ConsistencyLevel) (*sql.Rows, error) {
+	return s.db.Query(stmt.Query, stmt.Args...)
+}
+
+func (s *LocalStore) IsLeader() bool {
+	return true
+}
@@ -0,0 +1,73 @@
+package db
+
+import "testing"
+
+func TestLocalStore_ExecuteCommitsAllStatementsTogether(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewLocalStore(database)
+	results, err := store.Execute([]Statement{
+		{Query: `INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+			VALUES (?, 'Test?', 'BINARY', 'cpmm-1', 'user1', 1700000000000, 1800000000000, 'https://example.com')`, Args: []any{"m1"}},
+		{Query: `INSERT INTO market_snapshots (market_id, probability, volume, volume_24h, total_liquidity)
+			VALUES (?, 0.5, 100, 10, 50)`, Args: []any{"m1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[1].RowsAffected != 1 {
+		t.Errorf("expected second insert to affect 1 row, got %d", results[1].RowsAffected)
+	}
+}
+
+func TestLocalStore_ExecuteRollsBackOnFailure(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if err := Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewLocalStore(database)
+	_, err = store.Execute([]Statement{
+		{Query: `INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+			VALUES ('m1', 'Test?', 'BINARY', 'cpmm-1', 'user1', 1700000000000, 1800000000000, 'https://example.com')`},
+		{Query: `INSERT INTO nonexistent_table (id) VALUES (1)`},
+	})
+	if err == nil {
+		t.Fatal("expected an error from the invalid second statement")
+	}
+
+	var count int
+	if err := database.QueryRow(`SELECT COUNT(*) FROM markets`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the first insert to be rolled back too, got %d markets", count)
+	}
+}
+
+func TestLocalStore_IsLeaderAlwaysTrue(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	store := NewLocalStore(database)
+	if !store.IsLeader() {
+		t.Error("expected LocalStore to always report itself as leader")
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"sort"
 	"strings"
 
 	"github.com/jonnyspicer/mango"
 
+	"hyperkaehler/internal/retry"
 	"hyperkaehler/internal/risk"
 )
 
@@ -17,6 +19,7 @@ type Executor struct {
 	client     *mango.Client
 	db         *sql.DB
 	failedBets map[string]int // "marketID:answerID" -> consecutive failure count
+	orderBook  *ActiveOrderBook
 }
 
 func NewExecutor(client *mango.Client, db *sql.DB) *Executor {
@@ -27,6 +30,12 @@ func NewExecutor(client *mango.Client, db *sql.DB) *Executor {
 	}
 }
 
+// SetOrderBook wires an ActiveOrderBook that every successfully-placed
+// limit order is Track-ed in, so Reconcile starts watching it for fills.
+func (e *Executor) SetOrderBook(ob *ActiveOrderBook) {
+	e.orderBook = ob
+}
+
 // ExecutionResult records what happened when a signal was executed.
 type ExecutionResult struct {
 	Signal  risk.SizedSignal
@@ -34,24 +43,90 @@ type ExecutionResult struct {
 	Error   error
 }
 
-// Execute places bets for all sized signals and records them in the database.
+// Execute places bets for all sized signals and records them in the
+// database. Signals sharing a HedgePairID are placed as a unit: if one leg
+// fails, its sibling is cancelled (or never placed) rather than left as a
+// naked position.
 func (e *Executor) Execute(signals []risk.SizedSignal) []ExecutionResult {
 	results := make([]ExecutionResult, 0, len(signals))
 
+	hedgePairs := make(map[string][]risk.SizedSignal)
+	var solo []risk.SizedSignal
 	for _, sig := range signals {
-		result := e.executeSingle(sig)
+		if sig.Signal.HedgePairID == "" {
+			solo = append(solo, sig)
+			continue
+		}
+		hedgePairs[sig.Signal.HedgePairID] = append(hedgePairs[sig.Signal.HedgePairID], sig)
+	}
+
+	for _, sig := range solo {
+		result, _ := e.executeSingle(sig)
 		results = append(results, result)
 	}
 
+	pairIDs := make([]string, 0, len(hedgePairs))
+	for id := range hedgePairs {
+		pairIDs = append(pairIDs, id)
+	}
+	sort.Strings(pairIDs) // deterministic execution order
+
+	for _, id := range pairIDs {
+		results = append(results, e.executeHedgePair(hedgePairs[id])...)
+	}
+
 	return results
 }
 
-func (e *Executor) executeSingle(sig risk.SizedSignal) ExecutionResult {
+// executeHedgePair places both legs of a HedgePairID group or neither. legs
+// must contain exactly 2 signals — if the risk manager dropped one (e.g. an
+// exposure cap), the surviving leg is cancelled rather than placed naked.
+// If the first leg places successfully but the second fails, the first is
+// unwound via CancelBet so neither side is left exposed alone.
+func (e *Executor) executeHedgePair(legs []risk.SizedSignal) []ExecutionResult {
+	if len(legs) != 2 {
+		slog.Warn("hedge pair incomplete, cancelling all legs", "pair_id", legs[0].Signal.HedgePairID, "legs", len(legs))
+		results := make([]ExecutionResult, len(legs))
+		for i, sig := range legs {
+			results[i] = ExecutionResult{Signal: sig, Success: false, Error: fmt.Errorf("hedge pair incomplete: only %d of 2 legs sized", len(legs))}
+		}
+		return results
+	}
+
+	firstResult, firstBet := e.executeSingle(legs[0])
+	if !firstResult.Success {
+		slog.Warn("hedge pair cancelled: first leg failed, not placing sibling", "pair_id", legs[0].Signal.HedgePairID)
+		return []ExecutionResult{
+			firstResult,
+			{Signal: legs[1], Success: false, Error: fmt.Errorf("hedge pair cancelled: sibling leg failed to place")},
+		}
+	}
+
+	secondResult, _ := e.executeSingle(legs[1])
+	if secondResult.Success {
+		return []ExecutionResult{firstResult, secondResult}
+	}
+
+	if firstBet == nil {
+		return []ExecutionResult{firstResult, secondResult}
+	}
+	if err := e.client.CancelBet(firstBet.Id); err != nil {
+		slog.Error("failed to unwind hedge leg after sibling failure", "bet_id", firstBet.Id, "pair_id", legs[0].Signal.HedgePairID, "error", err)
+		return []ExecutionResult{firstResult, secondResult}
+	}
+
+	slog.Warn("hedge pair cancelled: unwound first leg after sibling failure", "bet_id", firstBet.Id, "pair_id", legs[0].Signal.HedgePairID)
+	firstResult.Success = false
+	firstResult.Error = fmt.Errorf("hedge pair cancelled: sibling leg failed, leg unwound")
+	return []ExecutionResult{firstResult, secondResult}
+}
+
+func (e *Executor) executeSingle(sig risk.SizedSignal) (ExecutionResult, *mango.Bet) {
 	// Skip bets that have failed 3+ times consecutively (e.g., resolved answers).
 	key := sig.Signal.MarketID + ":" + sig.Signal.AnswerID
 	if e.failedBets[key] >= 3 {
 		slog.Info("skipping repeatedly failed bet", "market", sig.Signal.MarketID, "answer", sig.Signal.AnswerID)
-		return ExecutionResult{Signal: sig, Success: false, Error: fmt.Errorf("skipped: failed %d times", e.failedBets[key])}
+		return ExecutionResult{Signal: sig, Success: false, Error: fmt.Errorf("skipped: failed %d times", e.failedBets[key])}, nil
 	}
 
 	slog.Info("placing bet",
@@ -77,7 +152,12 @@ func (e *Executor) executeSingle(sig risk.SizedSignal) ExecutionResult {
 		}
 	}
 
-	_, err := e.client.PostBet(req)
+	var bet *mango.Bet
+	err := retry.Do("post_bet", retry.LiteBackoff(), retry.IsRetryableHTTPError, func() error {
+		var postErr error
+		bet, postErr = e.client.PostBet(req)
+		return postErr
+	})
 
 	if err != nil {
 		errStr := err.Error()
@@ -97,15 +177,22 @@ func (e *Executor) executeSingle(sig risk.SizedSignal) ExecutionResult {
 			"error", err,
 			"consecutive_failures", e.failedBets[key],
 		)
-		return ExecutionResult{Signal: sig, Success: false, Error: err}
+		return ExecutionResult{Signal: sig, Success: false, Error: err}, nil
 	}
 	delete(e.failedBets, key) // Reset on success.
 
 	// Record in database.
-	if dbErr := e.recordBet(sig); dbErr != nil {
+	betID, dbErr := e.recordBet(sig)
+	if dbErr != nil {
 		slog.Error("failed to record bet in db", "error", dbErr)
 	}
 
+	if dbErr == nil && sig.Signal.IsLimitOrder && e.orderBook != nil {
+		if err := e.orderBook.Track(betID, bet.Id, sig.Signal.MarketID, sig.Signal.AnswerID, sig.Signal.Strategy, sig.Signal.Outcome, sig.Amount, sig.Signal.LimitProb); err != nil {
+			slog.Error("failed to track active order", "error", err)
+		}
+	}
+
 	slog.Info("bet placed successfully",
 		"market", sig.Signal.MarketID,
 		"answer", sig.Signal.AnswerID,
@@ -114,19 +201,27 @@ func (e *Executor) executeSingle(sig risk.SizedSignal) ExecutionResult {
 		"strategy", sig.Signal.Strategy,
 	)
 
-	return ExecutionResult{Signal: sig, Success: true}
+	return ExecutionResult{Signal: sig, Success: true}, bet
 }
 
-func (e *Executor) recordBet(sig risk.SizedSignal) error {
+// recordBet inserts sig as a bot_bets row and returns its id. A limit order
+// starts life as status "open" with filled_amount 0, since placing it on
+// Manifold doesn't guarantee an immediate fill; a market order is recorded
+// already "filled" for its full amount.
+func (e *Executor) recordBet(sig risk.SizedSignal) (int64, error) {
 	var limitProb *float64
+	status := OrderStatusFilled
+	filledAmount := sig.Amount
 	if sig.Signal.IsLimitOrder {
 		lp := sig.Signal.LimitProb
 		limitProb = &lp
+		status = OrderStatusOpen
+		filledAmount = 0
 	}
 
-	_, err := e.db.Exec(`
-		INSERT INTO bot_bets (market_id, strategy, outcome, amount, limit_prob, expected_prob, market_prob_at_bet, kelly_fraction)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+	res, err := e.db.Exec(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, limit_prob, expected_prob, market_prob_at_bet, kelly_fraction, layer_index, filled_amount, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		sig.Signal.MarketID,
 		sig.Signal.Strategy,
 		sig.Signal.Outcome,
@@ -135,11 +230,14 @@ func (e *Executor) recordBet(sig risk.SizedSignal) error {
 		sig.Signal.Confidence,
 		sig.Signal.MarketProb,
 		0,
+		sig.Signal.LayerIndex,
+		filledAmount,
+		status,
 	)
 	if err != nil {
-		return fmt.Errorf("inserting bot_bet: %w", err)
+		return 0, fmt.Errorf("inserting bot_bet: %w", err)
 	}
-	return nil
+	return res.LastInsertId()
 }
 
 // EnsureMarketExists inserts a market into the DB if it doesn't already exist.
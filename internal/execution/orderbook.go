@@ -0,0 +1,315 @@
+package execution
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jonnyspicer/mango"
+
+	"hyperkaehler/internal/retry"
+)
+
+// Order status values persisted on bot_bets.status.
+const (
+	OrderStatusOpen      = "open"
+	OrderStatusFilled    = "filled"
+	OrderStatusPartial   = "partial"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderEvent describes a tracked limit order's observed state, passed to
+// OnFill/OnCancel subscribers.
+type OrderEvent struct {
+	BetID        int64
+	MarketID     string
+	AnswerID     string
+	Outcome      string
+	LimitProb    float64
+	OrderAmount  float64
+	FilledAmount float64
+	Status       string
+}
+
+// OnFill and OnCancel are callbacks a strategy can Subscribe to in order to
+// react to a resting limit order changing state — e.g. market-making
+// re-quoting the opposite side once its limit order fills.
+type OnFill func(OrderEvent)
+type OnCancel func(OrderEvent)
+
+// ActiveOrderBook tracks resting limit orders keyed by
+// (market_id, answer_id, outcome, limit_prob) in the active_orders table,
+// and reconciles their fill state against Manifold each cycle via
+// Reconcile.
+type ActiveOrderBook struct {
+	client *mango.Client
+	db     *sql.DB
+	maxAge time.Duration
+
+	onFill   []OnFill
+	onCancel []OnCancel
+}
+
+func NewActiveOrderBook(client *mango.Client, db *sql.DB, maxAge time.Duration) *ActiveOrderBook {
+	return &ActiveOrderBook{client: client, db: db, maxAge: maxAge}
+}
+
+// Subscribe registers callbacks Reconcile invokes as fills/cancels are
+// observed. Typically called once at startup by each strategy that cares.
+func (ob *ActiveOrderBook) Subscribe(onFill OnFill, onCancel OnCancel) {
+	if onFill != nil {
+		ob.onFill = append(ob.onFill, onFill)
+	}
+	if onCancel != nil {
+		ob.onCancel = append(ob.onCancel, onCancel)
+	}
+}
+
+// Track records a newly-placed limit order so Reconcile starts watching it.
+// betID is the bot_bets row Executor.recordBet just inserted.
+func (ob *ActiveOrderBook) Track(betID int64, manifoldBetID, marketID, answerID, strategy, outcome string, amount, limitProb float64) error {
+	_, err := ob.db.Exec(`
+		INSERT INTO active_orders (bet_id, manifold_bet_id, market_id, answer_id, strategy, outcome, amount, limit_prob)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		betID, manifoldBetID, marketID, answerID, strategy, outcome, amount, limitProb,
+	)
+	if err != nil {
+		return fmt.Errorf("tracking active order: %w", err)
+	}
+	return nil
+}
+
+type activeOrder struct {
+	id            int64
+	betID         int64
+	manifoldBetID string
+	marketID      string
+	answerID      string
+	outcome       string
+	amount        float64
+	limitProb     float64
+	placedAt      time.Time
+}
+
+// Reconcile polls Manifold for the current state of every resting order
+// placed by userID, updates bot_bets/active_orders with what it observes,
+// fires OnFill/OnCancel for any state change, and cancels orders that have
+// rested unfilled longer than maxAge.
+func (ob *ActiveOrderBook) Reconcile(ctx context.Context, userID string) error {
+	orders, err := ob.openOrders()
+	if err != nil {
+		return fmt.Errorf("loading open orders: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	byBetID := make(map[string]activeOrder, len(orders))
+	oldest := orders[0].placedAt
+	for _, o := range orders {
+		byBetID[o.manifoldBetID] = o
+		if o.placedAt.Before(oldest) {
+			oldest = o.placedAt
+		}
+	}
+
+	bets, err := ob.fetchBetsSince(userID, oldest)
+	if err != nil {
+		return fmt.Errorf("fetching bet history: %w", err)
+	}
+
+	filled := make(map[int64]bool, len(orders))
+	for _, bet := range bets {
+		o, ok := byBetID[bet.Id]
+		if !ok {
+			continue
+		}
+		if err := ob.applyBetState(o, bet); err != nil {
+			slog.Error("orderbook: failed to apply reconciled state", "bet_id", bet.Id, "error", err)
+			continue
+		}
+		if bet.Amount >= o.amount-1e-9 {
+			filled[o.id] = true
+		}
+	}
+
+	return ob.cancelStale(orders, filled)
+}
+
+// fetchBetsSince pages through userID's bet history (newest-first, the same
+// shape risk.PortfolioFixer.Fix uses) back to `since`.
+func (ob *ActiveOrderBook) fetchBetsSince(userID string, since time.Time) ([]mango.Bet, error) {
+	const pageSize = 1000
+	var all []mango.Bet
+	var before string
+	for {
+		var page *[]mango.Bet
+		err := retry.Do("get_bets", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+			var getErr error
+			page, getErr = ob.client.GetBets(mango.GetBetsRequest{
+				UserId: userID,
+				Limit:  pageSize,
+				Before: before,
+			})
+			return getErr
+		})
+		if err != nil {
+			return nil, err
+		}
+		if page == nil || len(*page) == 0 {
+			break
+		}
+
+		batch := *page
+		stop := false
+		for _, bet := range batch {
+			if time.UnixMilli(bet.CreatedTime).Before(since) {
+				stop = true
+				break
+			}
+			all = append(all, bet)
+		}
+		if stop || len(batch) < pageSize {
+			break
+		}
+		before = batch[len(batch)-1].Id
+	}
+	return all, nil
+}
+
+// applyBetState records what Reconcile observed about a single tracked
+// order: bet.Amount is the amount actually filled so far (partial limit
+// fills arrive as the same bet ID with a growing Amount).
+func (ob *ActiveOrderBook) applyBetState(o activeOrder, bet mango.Bet) error {
+	status := OrderStatusPartial
+	if bet.Amount <= 1e-9 {
+		status = OrderStatusOpen
+	} else if bet.Amount >= o.amount-1e-9 {
+		status = OrderStatusFilled
+	}
+
+	var previouslyFilled float64
+	if err := ob.db.QueryRow(`SELECT filled_amount FROM bot_bets WHERE id = ?`, o.betID).Scan(&previouslyFilled); err != nil {
+		return fmt.Errorf("reading previous fill state: %w", err)
+	}
+
+	if _, err := ob.db.Exec(`UPDATE bot_bets SET filled_amount = ?, status = ? WHERE id = ?`,
+		bet.Amount, status, o.betID); err != nil {
+		return fmt.Errorf("updating bot_bets fill state: %w", err)
+	}
+
+	event := OrderEvent{
+		BetID:        o.betID,
+		MarketID:     o.marketID,
+		AnswerID:     o.answerID,
+		Outcome:      o.outcome,
+		LimitProb:    o.limitProb,
+		OrderAmount:  o.amount,
+		FilledAmount: bet.Amount,
+		Status:       status,
+	}
+
+	if status == OrderStatusFilled || status == OrderStatusPartial {
+		if bet.Amount > previouslyFilled+1e-9 {
+			for _, cb := range ob.onFill {
+				cb(event)
+			}
+		}
+	}
+
+	if status == OrderStatusFilled {
+		if _, err := ob.db.Exec(`UPDATE active_orders SET is_active = 0 WHERE id = ?`, o.id); err != nil {
+			return fmt.Errorf("deactivating filled order: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// cancelStale cancels any still-resting order (not observed filled this
+// cycle) older than maxAge, to free up the capital it's holding.
+func (ob *ActiveOrderBook) cancelStale(orders []activeOrder, filled map[int64]bool) error {
+	if ob.maxAge <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-ob.maxAge)
+	for _, o := range orders {
+		if filled[o.id] || o.placedAt.After(cutoff) {
+			continue
+		}
+
+		if err := ob.client.CancelBet(o.manifoldBetID); err != nil {
+			slog.Warn("orderbook: failed to cancel stale order", "bet_id", o.manifoldBetID, "error", err)
+			continue
+		}
+
+		if _, err := ob.db.Exec(`
+			UPDATE active_orders SET is_active = 0, cancelled_at = datetime('now') WHERE id = ?`, o.id,
+		); err != nil {
+			return fmt.Errorf("deactivating cancelled order: %w", err)
+		}
+		if _, err := ob.db.Exec(`UPDATE bot_bets SET status = ? WHERE id = ?`, OrderStatusCancelled, o.betID); err != nil {
+			return fmt.Errorf("marking bet cancelled: %w", err)
+		}
+
+		event := OrderEvent{
+			BetID:       o.betID,
+			MarketID:    o.marketID,
+			AnswerID:    o.answerID,
+			Outcome:     o.outcome,
+			LimitProb:   o.limitProb,
+			OrderAmount: o.amount,
+			Status:      OrderStatusCancelled,
+		}
+		for _, cb := range ob.onCancel {
+			cb(event)
+		}
+
+		slog.Info("orderbook: cancelled stale order",
+			"market", o.marketID,
+			"outcome", o.outcome,
+			"age", time.Since(o.placedAt),
+		)
+	}
+	return nil
+}
+
+func (ob *ActiveOrderBook) openOrders() ([]activeOrder, error) {
+	rows, err := ob.db.Query(`
+		SELECT id, bet_id, manifold_bet_id, market_id, answer_id, outcome, amount, limit_prob, placed_at
+		FROM active_orders WHERE is_active = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []activeOrder
+	for rows.Next() {
+		var o activeOrder
+		var betID sql.NullInt64
+		var manifoldBetID sql.NullString
+		var placedAt string
+		if err := rows.Scan(&o.id, &betID, &manifoldBetID, &o.marketID, &o.answerID, &o.outcome, &o.amount, &o.limitProb, &placedAt); err != nil {
+			return nil, err
+		}
+		o.betID = betID.Int64
+		o.manifoldBetID = manifoldBetID.String
+		parsed, err := time.Parse("2006-01-02 15:04:05", placedAt)
+		if err != nil {
+			parsed, err = time.Parse(time.RFC3339, placedAt)
+			if err != nil {
+				return nil, fmt.Errorf("parsing placed_at %q: %w", placedAt, err)
+			}
+		}
+		o.placedAt = parsed
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
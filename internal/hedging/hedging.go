@@ -0,0 +1,126 @@
+// Package hedging opens proportional offsetting bets in related markets
+// once risk.Manager has sized an entry on a declared primary market,
+// bounding the worst-case loss on correlated positions.
+//
+// Groups are declared explicitly in config (HedgingConfig.Groups) rather
+// than discovered automatically from market text similarity, and each leg
+// is sized as a flat ratio of the primary's amount rather than matched to
+// an equal share count or topped up against a tracked "covered position
+// per group" — so a hedge bounds, but doesn't exactly lock, the combined
+// position's worst case. Scoped down this way deliberately: automatic
+// group discovery needs a market-similarity signal (Jaccard over Question,
+// or an embedding index) this package doesn't have a source for yet.
+package hedging
+
+import (
+	"fmt"
+	"log/slog"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/risk"
+)
+
+// Hedger applies cfg.Groups to a sized-signal batch, emitting one extra
+// SizedSignal per hedge leg for every primary-market signal it matches.
+type Hedger struct {
+	cfg       config.HedgingConfig
+	byPrimary map[string][]config.HedgeLeg
+	riskMgr   *risk.Manager
+}
+
+func NewHedger(cfg config.HedgingConfig) *Hedger {
+	byPrimary := make(map[string][]config.HedgeLeg, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		byPrimary[g.Primary] = g.Hedges
+	}
+	return &Hedger{cfg: cfg, byPrimary: byPrimary}
+}
+
+// SetRiskManager wires the risk.Manager whose MaxMarketExposurePct cap
+// Apply enforces on hedge legs, the same cap primary signals are already
+// sized against in SizeSignals. A nil riskMgr (the default) leaves hedge
+// legs uncapped — only acceptable if MaxMarketExposurePct is unset.
+func (h *Hedger) SetRiskManager(riskMgr *risk.Manager) {
+	h.riskMgr = riskMgr
+}
+
+// Apply returns sized with one additional SizedSignal appended per hedge
+// leg configured for any primary market present in sized. Each hedge leg
+// shares its primary's HedgePairID so the executor places them atomically:
+// if the hedge fails to place, the primary is unwound rather than left as
+// a naked position. A primary with more than one hedge leg only pairs
+// atomically with the first; additional legs are best-effort.
+//
+// Hedge legs are bounded by the wired risk.Manager's MaxMarketExposurePct
+// cap (SetRiskManager), the same cap primary signals are already sized
+// against — otherwise a hedge leg could push its market past the cap
+// unchecked. committed tracks exposure already allocated to a market within
+// this single Apply call (across primaries and prior legs, none of which
+// risk.Manager has recorded yet via RecordTrade), so repeated hedges onto
+// the same leg market stack against each other rather than each being
+// capped independently against only the persisted exposure.
+func (h *Hedger) Apply(sized []risk.SizedSignal) []risk.SizedSignal {
+	if !h.cfg.Enabled || len(h.byPrimary) == 0 {
+		return sized
+	}
+
+	out := make([]risk.SizedSignal, len(sized))
+	copy(out, sized)
+
+	committed := make(map[string]float64, len(sized))
+	for _, sig := range sized {
+		committed[sig.Signal.MarketID] += sig.Amount
+	}
+
+	for i, sig := range sized {
+		legs, ok := h.byPrimary[sig.Signal.MarketID]
+		if !ok || sig.Amount <= 0 {
+			continue
+		}
+
+		legsOpened := 0
+		for j, leg := range legs {
+			if leg.Ratio <= 0 {
+				continue
+			}
+			hedgeAmount := sig.Amount * leg.Ratio
+			if h.riskMgr != nil {
+				hedgeAmount = h.riskMgr.CapToMarketExposure(leg.MarketID, hedgeAmount, committed[leg.MarketID])
+			}
+			if hedgeAmount <= 0 {
+				slog.Info("hedging: leg rejected by market exposure cap",
+					"primary_market", sig.Signal.MarketID,
+					"leg_market", leg.MarketID,
+				)
+				continue
+			}
+
+			hedgeSignal := sig.Signal
+			hedgeSignal.MarketID = leg.MarketID
+			hedgeSignal.Outcome = leg.Outcome
+			hedgeSignal.Strategy = "hedging"
+			hedgeSignal.Reason = fmt.Sprintf("hedge of %s (ratio %.2f, correlation %.2f) against primary %s signal from %s",
+				leg.MarketID, leg.Ratio, leg.Correlation, sig.Signal.MarketID, sig.Signal.Strategy)
+			hedgeSignal.HedgePairID = ""
+			hedgeSignal.SizeMultiplier = 0
+
+			if j == 0 {
+				pairID := fmt.Sprintf("hedge-group:%s:%s", sig.Signal.MarketID, leg.MarketID)
+				out[i].Signal.HedgePairID = pairID
+				hedgeSignal.HedgePairID = pairID
+			}
+
+			committed[leg.MarketID] += hedgeAmount
+			out = append(out, risk.SizedSignal{Signal: hedgeSignal, Amount: hedgeAmount})
+			legsOpened++
+		}
+
+		slog.Info("hedging: opened hedge legs for primary signal",
+			"primary_market", sig.Signal.MarketID,
+			"primary_amount", sig.Amount,
+			"legs", legsOpened,
+		)
+	}
+
+	return out
+}
@@ -0,0 +1,134 @@
+package hedging
+
+import (
+	"testing"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/risk"
+	"hyperkaehler/internal/strategy"
+)
+
+func TestHedger_OpensProportionalHedgeLeg(t *testing.T) {
+	h := NewHedger(config.HedgingConfig{
+		Enabled: true,
+		Groups: []config.HedgeGroup{
+			{
+				Primary: "market-A",
+				Hedges: []config.HedgeLeg{
+					{MarketID: "market-B", Outcome: "NO", Ratio: 0.5, Correlation: 0.9},
+				},
+			},
+		},
+	})
+
+	sized := []risk.SizedSignal{
+		{Signal: strategy.Signal{MarketID: "market-A", Outcome: "YES", Strategy: "arbitrage"}, Amount: 100},
+	}
+
+	out := h.Apply(sized)
+	if len(out) != 2 {
+		t.Fatalf("expected primary + 1 hedge leg, got %d", len(out))
+	}
+
+	hedgeLeg := out[1]
+	if hedgeLeg.Signal.MarketID != "market-B" || hedgeLeg.Signal.Outcome != "NO" {
+		t.Fatalf("expected hedge leg on market-B/NO, got %+v", hedgeLeg.Signal)
+	}
+	if hedgeLeg.Amount != 50 {
+		t.Errorf("expected hedge amount 50 (ratio 0.5 of 100), got %f", hedgeLeg.Amount)
+	}
+	if hedgeLeg.Signal.HedgePairID == "" || hedgeLeg.Signal.HedgePairID != out[0].Signal.HedgePairID {
+		t.Errorf("expected hedge leg and primary to share a HedgePairID, got %+v and %+v", out[0].Signal, hedgeLeg.Signal)
+	}
+}
+
+func TestHedger_DisabledPassesThrough(t *testing.T) {
+	h := NewHedger(config.HedgingConfig{Enabled: false})
+
+	sized := []risk.SizedSignal{
+		{Signal: strategy.Signal{MarketID: "market-A", Outcome: "YES"}, Amount: 100},
+	}
+
+	out := h.Apply(sized)
+	if len(out) != 1 {
+		t.Fatalf("expected no hedge legs while disabled, got %d", len(out))
+	}
+}
+
+func TestHedger_LegCappedByMarketExposure(t *testing.T) {
+	portfolio := &risk.Portfolio{Balance: 1000, TotalValue: 1000}
+	riskMgr := risk.NewManager(config.RiskConfig{MaxMarketExposurePct: 0.10}, portfolio)
+	riskMgr.SetMarketExposure(map[string]float64{"market-B": 90}) // 10 of the 100 cap left.
+
+	h := NewHedger(config.HedgingConfig{
+		Enabled: true,
+		Groups: []config.HedgeGroup{
+			{
+				Primary: "market-A",
+				Hedges: []config.HedgeLeg{
+					{MarketID: "market-B", Outcome: "NO", Ratio: 0.5, Correlation: 0.9},
+				},
+			},
+		},
+	})
+	h.SetRiskManager(riskMgr)
+
+	sized := []risk.SizedSignal{
+		{Signal: strategy.Signal{MarketID: "market-A", Outcome: "YES", Strategy: "arbitrage"}, Amount: 100},
+	}
+
+	out := h.Apply(sized)
+	if len(out) != 2 {
+		t.Fatalf("expected primary + 1 capped hedge leg, got %d", len(out))
+	}
+	// Ratio would ask for 50, but only 10 of market-B's exposure budget remains.
+	if out[1].Amount != 10 {
+		t.Errorf("expected hedge leg capped to remaining 10 exposure budget, got %f", out[1].Amount)
+	}
+}
+
+func TestHedger_LegDroppedWhenExposureCapExhausted(t *testing.T) {
+	portfolio := &risk.Portfolio{Balance: 1000, TotalValue: 1000}
+	riskMgr := risk.NewManager(config.RiskConfig{MaxMarketExposurePct: 0.10}, portfolio)
+	riskMgr.SetMarketExposure(map[string]float64{"market-B": 100}) // Cap already reached.
+
+	h := NewHedger(config.HedgingConfig{
+		Enabled: true,
+		Groups: []config.HedgeGroup{
+			{
+				Primary: "market-A",
+				Hedges: []config.HedgeLeg{
+					{MarketID: "market-B", Outcome: "NO", Ratio: 0.5, Correlation: 0.9},
+				},
+			},
+		},
+	})
+	h.SetRiskManager(riskMgr)
+
+	sized := []risk.SizedSignal{
+		{Signal: strategy.Signal{MarketID: "market-A", Outcome: "YES", Strategy: "arbitrage"}, Amount: 100},
+	}
+
+	out := h.Apply(sized)
+	if len(out) != 1 {
+		t.Fatalf("expected the hedge leg to be dropped once the market exposure cap is exhausted, got %d", len(out))
+	}
+}
+
+func TestHedger_NoMatchingGroupPassesThrough(t *testing.T) {
+	h := NewHedger(config.HedgingConfig{
+		Enabled: true,
+		Groups: []config.HedgeGroup{
+			{Primary: "market-X", Hedges: []config.HedgeLeg{{MarketID: "market-Y", Outcome: "NO", Ratio: 1}}},
+		},
+	})
+
+	sized := []risk.SizedSignal{
+		{Signal: strategy.Signal{MarketID: "market-A", Outcome: "YES"}, Amount: 100},
+	}
+
+	out := h.Apply(sized)
+	if len(out) != 1 {
+		t.Fatalf("expected no hedge legs for an unmatched primary, got %d", len(out))
+	}
+}
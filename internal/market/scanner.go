@@ -8,6 +8,7 @@ import (
 
 	"github.com/jonnyspicer/mango"
 
+	"hyperkaehler/internal/retry"
 	"hyperkaehler/internal/strategy"
 )
 
@@ -22,11 +23,16 @@ func NewScanner(client *mango.Client) *Scanner {
 
 // ScanBinary fetches open binary markets sorted by liquidity.
 func (s *Scanner) ScanBinary(limit int64) ([]strategy.MarketData, error) {
-	markets, err := s.client.SearchMarkets(mango.SearchMarketsRequest{
-		Filter:       "open",
-		ContractType: "BINARY",
-		Sort:         "liquidity",
-		Limit:        limit,
+	var markets *[]mango.FullMarket
+	err := retry.Do("scan_binary", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+		var searchErr error
+		markets, searchErr = s.client.SearchMarkets(mango.SearchMarketsRequest{
+			Filter:       "open",
+			ContractType: "BINARY",
+			Sort:         "liquidity",
+			Limit:        limit,
+		})
+		return searchErr
 	})
 	if err != nil {
 		return nil, fmt.Errorf("searching binary markets: %w", err)
@@ -46,11 +52,16 @@ func (s *Scanner) ScanBinary(limit int64) ([]strategy.MarketData, error) {
 // ScanMultipleChoice fetches open multiple-choice markets sorted by liquidity,
 // then enriches them with answer probabilities via the batch probability API.
 func (s *Scanner) ScanMultipleChoice(limit int64) ([]strategy.MarketData, error) {
-	markets, err := s.client.SearchMarkets(mango.SearchMarketsRequest{
-		Filter:       "open",
-		ContractType: "MULTIPLE_CHOICE",
-		Sort:         "liquidity",
-		Limit:        limit,
+	var markets *[]mango.FullMarket
+	err := retry.Do("scan_multiple_choice", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+		var searchErr error
+		markets, searchErr = s.client.SearchMarkets(mango.SearchMarketsRequest{
+			Filter:       "open",
+			ContractType: "MULTIPLE_CHOICE",
+			Sort:         "liquidity",
+			Limit:        limit,
+		})
+		return searchErr
 	})
 	if err != nil {
 		return nil, fmt.Errorf("searching multi-choice markets: %w", err)
@@ -224,10 +235,15 @@ func (s *Scanner) enrichWithResolution(markets []strategy.MarketData) {
 
 // ScanAll fetches all open markets (binary + multi-choice) for the collector.
 func (s *Scanner) ScanAll(limit int64) ([]strategy.MarketData, error) {
-	markets, err := s.client.SearchMarkets(mango.SearchMarketsRequest{
-		Filter: "open",
-		Sort:   "liquidity",
-		Limit:  limit,
+	var markets *[]mango.FullMarket
+	err := retry.Do("scan_all", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+		var searchErr error
+		markets, searchErr = s.client.SearchMarkets(mango.SearchMarketsRequest{
+			Filter: "open",
+			Sort:   "liquidity",
+			Limit:  limit,
+		})
+		return searchErr
 	})
 	if err != nil {
 		return nil, fmt.Errorf("searching all markets: %w", err)
@@ -0,0 +1,184 @@
+package performance
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jonnyspicer/mango"
+
+	"hyperkaehler/internal/risk"
+)
+
+// Fixer is the full-recovery counterpart to risk.PortfolioFixer: where that
+// fixer runs opportunistically whenever Portfolio.Refresh notices drift,
+// Fixer is the deliberate "rebuild everything" path invoked from the
+// -fix-from CLI flag for when the SQLite DB itself was lost, corrupted,
+// or ran out of sync with Manifold. It rebuilds bot_bets from the bot's full
+// bet history, reconciles every touched market's resolution, and re-emits a
+// full performance Report so the operator can see what came back.
+type Fixer struct {
+	client  *mango.Client
+	db      *sql.DB
+	betFix  *risk.PortfolioFixer
+	tracker *Tracker
+	breaker risk.CircuitBreaker
+}
+
+func NewFixer(client *mango.Client, db *sql.DB) *Fixer {
+	return &Fixer{
+		client:  client,
+		db:      db,
+		betFix:  risk.NewPortfolioFixer(client, db),
+		tracker: NewTracker(db),
+	}
+}
+
+// SetCircuitBreaker wires a risk.CircuitBreaker that reconcileResolutions
+// notifies of each bet's realized PnL as markets resolve, via
+// RecordSettlement — this is the only place in the codebase a settlement is
+// currently observed as it happens, rather than inferred by querying
+// bot_bets later. A nil breaker (the default) skips the notification.
+func (f *Fixer) SetCircuitBreaker(cb risk.CircuitBreaker) { f.breaker = cb }
+
+// Run rebuilds bot_bets from the bot's bet history since `since` via
+// risk.PortfolioFixer (idempotent, upserting on manifold_bet_id — this
+// already folds partially-filled limit orders correctly, since a limit
+// order is one bet in the history whose Amount is the amount filled so far,
+// and a re-run refreshes that amount), reconciles
+// resolutions on every market touched, and logs the resulting report.
+// It is safe to call Scheduler.loadMarketExposure immediately afterwards:
+// reconcileResolutions always runs before Run returns, so bot_bets.resolved
+// reflects Manifold's current state rather than whatever was true when each
+// bet was originally placed.
+func (f *Fixer) Run(since time.Time) (*Report, error) {
+	summary, err := f.betFix.Fix(since)
+	if err != nil {
+		return nil, fmt.Errorf("rebuilding bet history: %w", err)
+	}
+
+	marketIDs, err := f.touchedMarkets(since)
+	if err != nil {
+		return nil, fmt.Errorf("listing touched markets: %w", err)
+	}
+	if err := f.reconcileResolutions(marketIDs); err != nil {
+		return nil, fmt.Errorf("reconciling resolutions: %w", err)
+	}
+
+	report, err := f.tracker.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("generating report: %w", err)
+	}
+	LogReport(report)
+
+	slog.Info("fixer run complete",
+		"bets_processed", summary.BetsProcessed,
+		"markets_rebuilt", summary.MarketsBuilt,
+		"markets_reconciled", len(marketIDs),
+	)
+	return report, nil
+}
+
+// touchedMarkets returns every market_id with at least one bot_bets row
+// placed since `since` — everything Fix could plausibly have rebuilt, and
+// therefore everything worth re-checking for a resolution.
+func (f *Fixer) touchedMarkets(since time.Time) ([]string, error) {
+	cutoff := since.UTC().Format("2006-01-02 15:04:05")
+	rows, err := f.db.Query(`SELECT DISTINCT market_id FROM bot_bets WHERE placed_at >= ?`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// reconcileResolutions re-fetches each market's current state from
+// Manifold and, for markets that have since resolved, marks every
+// unresolved bot_bets row against it resolved with a realized PnL.
+func (f *Fixer) reconcileResolutions(marketIDs []string) error {
+	for _, id := range marketIDs {
+		m, err := f.client.GetMarketByID(id)
+		if err != nil {
+			slog.Warn("fixer: failed to refetch market for resolution check", "market", id, "error", err)
+			continue
+		}
+		if m == nil || !m.IsResolved {
+			continue
+		}
+
+		if _, err := f.db.Exec(`
+			UPDATE markets SET is_resolved = 1, resolution = ? WHERE id = ?`,
+			m.Resolution, id,
+		); err != nil {
+			return fmt.Errorf("updating market %s: %w", id, err)
+		}
+
+		if err := f.resolveBets(id, m.Resolution); err != nil {
+			return fmt.Errorf("resolving bets for market %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// resolveBets marks every unresolved bot_bets row for a market resolved.
+// bot_bets doesn't persist share counts, so PnL is approximated: a bet on
+// the winning outcome nets amount/market_prob_at_bet - amount (its payout at the
+// probability it was filled at, minus its stake), a bet on the losing
+// outcome nets -amount.
+func (f *Fixer) resolveBets(marketID, resolution string) error {
+	rows, err := f.db.Query(`
+		SELECT id, outcome, amount, market_prob_at_bet
+		FROM bot_bets WHERE market_id = ? AND resolved = 0`, marketID)
+	if err != nil {
+		return err
+	}
+
+	type unresolvedBet struct {
+		id      int64
+		outcome string
+		amount  float64
+		prob    float64
+	}
+	var bets []unresolvedBet
+	for rows.Next() {
+		var b unresolvedBet
+		if err := rows.Scan(&b.id, &b.outcome, &b.amount, &b.prob); err != nil {
+			rows.Close()
+			return err
+		}
+		bets = append(bets, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range bets {
+		var pnl float64
+		if b.outcome == resolution && b.prob > 0 {
+			pnl = b.amount/b.prob - b.amount
+		} else {
+			pnl = -b.amount
+		}
+		if _, err := f.db.Exec(`
+			UPDATE bot_bets SET resolved = 1, resolution = ?, pnl = ?, resolved_at = datetime('now')
+			WHERE id = ?`, resolution, pnl, b.id,
+		); err != nil {
+			return err
+		}
+		if f.breaker != nil {
+			f.breaker.RecordSettlement(marketID, pnl)
+		}
+	}
+	return nil
+}
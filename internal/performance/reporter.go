@@ -26,6 +26,30 @@ func LogReport(r *Report) {
 			"roi", stats.ROI,
 			"win_rate", stats.WinRate,
 			"avg_edge", stats.AvgEdge,
+			"circuit_breaker_tripped", stats.CircuitBreakerTripped,
+			"circuit_breaker_reason", stats.CircuitBreakerReason,
+		)
+	}
+}
+
+// LogTradeStats logs each strategy's rolling TradeStats snapshot as
+// structured JSON, in the same shape LogReport uses for per-strategy rows.
+func LogTradeStats(stats []TradeStats) {
+	slog.Info("=== STRATEGY STATS SNAPSHOT ===", "strategies", len(stats))
+
+	for _, s := range stats {
+		slog.Info("strategy stats",
+			"strategy", s.Strategy,
+			"sample_size", s.SampleSize,
+			"sharpe", s.Sharpe,
+			"sortino", s.Sortino,
+			"profit_factor", s.ProfitFactor,
+			"avg_claimed_edge", s.AvgClaimedEdge,
+			"avg_realized_edge", s.AvgRealizedEdge,
+			"current_win_streak", s.CurrentWinStreak,
+			"current_loss_streak", s.CurrentLossStreak,
+			"max_win_streak", s.MaxWinStreak,
+			"max_loss_streak", s.MaxLossStreak,
 		)
 	}
 }
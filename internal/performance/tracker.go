@@ -37,6 +37,12 @@ type StrategyStats struct {
 	ROI         float64
 	WinRate     float64
 	AvgEdge     float64
+
+	// CircuitBreakerTripped and CircuitBreakerReason reflect the most recent
+	// unreset row in circuit_breaker_events that applies to this strategy —
+	// either tripped specifically for it, or tripped portfolio-wide.
+	CircuitBreakerTripped bool
+	CircuitBreakerReason  string
 }
 
 // Generate computes the full performance report.
@@ -54,6 +60,9 @@ func (t *Tracker) Generate() (*Report, error) {
 	if err := t.computeDrawdown(r); err != nil {
 		return nil, fmt.Errorf("computing drawdown: %w", err)
 	}
+	if err := t.computeCircuitBreakerStatus(r); err != nil {
+		return nil, fmt.Errorf("computing circuit breaker status: %w", err)
+	}
 
 	return r, nil
 }
@@ -149,3 +158,32 @@ func (t *Tracker) computeDrawdown(r *Report) error {
 	r.MaxDrawdown = maxDD
 	return rows.Err()
 }
+
+// computeCircuitBreakerStatus surfaces the currently-active circuit breaker
+// trip (if any) onto affected strategies' stats. A trip recorded with an
+// empty strategy column applies to every strategy; one recorded for a
+// specific strategy only marks that entry.
+func (t *Tracker) computeCircuitBreakerStatus(r *Report) error {
+	var reason, strategyName string
+	err := t.db.QueryRow(`
+		SELECT reason, strategy FROM circuit_breaker_events
+		WHERE reset_at IS NULL
+		ORDER BY id DESC LIMIT 1`,
+	).Scan(&reason, &strategyName)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for name, stats := range r.StrategyStats {
+		if strategyName != "" && strategyName != name {
+			continue
+		}
+		stats.CircuitBreakerTripped = true
+		stats.CircuitBreakerReason = reason
+		r.StrategyStats[name] = stats
+	}
+	return nil
+}
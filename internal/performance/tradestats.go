@@ -0,0 +1,330 @@
+package performance
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+// defaultTradeStatsWindow is the fallback sample size when
+// config.PerformanceConfig.TradeStatsWindowSize is unset.
+const defaultTradeStatsWindow = 100
+
+// TradeStats is a strategy's rolling, realized track record over its most
+// recent resolved bets: Sharpe/Sortino ratios, profit factor, claimed vs.
+// realized edge, and win/loss streak distribution. Unlike Report's
+// per-strategy totals (all-time), TradeStats is windowed so a strategy
+// whose edge has drifted reacts quickly rather than being diluted by a
+// long history of stale bets.
+type TradeStats struct {
+	Strategy          string
+	SampleSize        int
+	Sharpe            float64
+	Sortino           float64
+	ProfitFactor      float64
+	AvgClaimedEdge    float64
+	AvgRealizedEdge   float64
+	CurrentWinStreak  int
+	CurrentLossStreak int
+	MaxWinStreak      int
+	MaxLossStreak     int
+}
+
+// TradeStatsTracker computes and persists rolling TradeStats per strategy,
+// and answers the fractional-Kelly multiplier strategies scale their
+// signals by based on that realized track record.
+type TradeStatsTracker struct {
+	db         *sql.DB
+	windowSize int
+	minSample  int
+}
+
+// NewTradeStatsTracker returns a tracker windowed to the most recent
+// windowSize resolved bets per strategy (0 means defaultTradeStatsWindow).
+// minSample is the resolved-bet count below which StrategyMultiplier
+// returns 1.0 rather than trusting a thin sample.
+func NewTradeStatsTracker(db *sql.DB, windowSize, minSample int) *TradeStatsTracker {
+	if windowSize <= 0 {
+		windowSize = defaultTradeStatsWindow
+	}
+	return &TradeStatsTracker{db: db, windowSize: windowSize, minSample: minSample}
+}
+
+// Compute recomputes TradeStats for every strategy with resolved bets and
+// persists each to strategy_stats_snapshots, so StrategyMultiplier's input
+// is stable across restarts rather than recomputed on every call.
+func (t *TradeStatsTracker) Compute() ([]TradeStats, error) {
+	rows, err := t.db.Query(`SELECT DISTINCT strategy FROM bot_bets WHERE resolved = 1`)
+	if err != nil {
+		return nil, fmt.Errorf("listing strategies: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	all := make([]TradeStats, 0, len(names))
+	for _, name := range names {
+		stats, err := t.computeOne(name)
+		if err != nil {
+			return nil, fmt.Errorf("computing stats for %s: %w", name, err)
+		}
+		if err := t.persist(stats); err != nil {
+			return nil, fmt.Errorf("persisting stats for %s: %w", name, err)
+		}
+		all = append(all, stats)
+	}
+	return all, nil
+}
+
+// resolvedTrade is one resolved bet's economics, as needed to fold into
+// TradeStats.
+type resolvedTrade struct {
+	amount      float64
+	pnl         float64
+	claimedEdge float64
+}
+
+func (t *TradeStatsTracker) computeOne(strategyName string) (TradeStats, error) {
+	rows, err := t.db.Query(`
+		SELECT amount, pnl, expected_prob, market_prob_at_bet, outcome
+		FROM bot_bets
+		WHERE strategy = ? AND resolved = 1
+		ORDER BY id DESC
+		LIMIT ?`, strategyName, t.windowSize)
+	if err != nil {
+		return TradeStats{}, err
+	}
+	defer rows.Close()
+
+	// Bets come back newest-first; reverse once collected so streaks are
+	// computed in chronological order.
+	var trades []resolvedTrade
+	for rows.Next() {
+		var amount, pnl, expectedProb, marketProb float64
+		var outcome string
+		if err := rows.Scan(&amount, &pnl, &expectedProb, &marketProb, &outcome); err != nil {
+			return TradeStats{}, err
+		}
+		claimedEdge := expectedProb - marketProb
+		if outcome == "NO" {
+			claimedEdge = (1 - expectedProb) - (1 - marketProb)
+		}
+		trades = append(trades, resolvedTrade{amount: amount, pnl: pnl, claimedEdge: claimedEdge})
+	}
+	if err := rows.Err(); err != nil {
+		return TradeStats{}, err
+	}
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+
+	stats := TradeStats{Strategy: strategyName, SampleSize: len(trades)}
+	if len(trades) == 0 {
+		return stats, nil
+	}
+
+	var grossProfit, grossLoss, claimedSum, realizedSum float64
+	var winStreak, lossStreak int
+	returns := make([]float64, 0, len(trades))
+
+	for _, tr := range trades {
+		claimedSum += tr.claimedEdge
+		if tr.amount > 0 {
+			realizedEdge := tr.pnl / tr.amount
+			realizedSum += realizedEdge
+			returns = append(returns, realizedEdge)
+		}
+
+		switch {
+		case tr.pnl > 0:
+			grossProfit += tr.pnl
+			winStreak++
+			lossStreak = 0
+		case tr.pnl < 0:
+			grossLoss += -tr.pnl
+			lossStreak++
+			winStreak = 0
+		}
+		if winStreak > stats.MaxWinStreak {
+			stats.MaxWinStreak = winStreak
+		}
+		if lossStreak > stats.MaxLossStreak {
+			stats.MaxLossStreak = lossStreak
+		}
+	}
+
+	stats.CurrentWinStreak = winStreak
+	stats.CurrentLossStreak = lossStreak
+	stats.AvgClaimedEdge = claimedSum / float64(len(trades))
+	stats.AvgRealizedEdge = realizedSum / float64(len(trades))
+	stats.Sharpe = sharpeRatio(returns)
+	stats.Sortino = sortinoRatio(returns)
+	switch {
+	case grossLoss > 0:
+		stats.ProfitFactor = grossProfit / grossLoss
+	case grossProfit > 0:
+		// No losses at all across the window: treat as unboundedly good
+		// rather than the zero value, which would otherwise read as the
+		// worst possible profit factor in StrategyMultiplier.
+		stats.ProfitFactor = math.Inf(1)
+	}
+
+	return stats, nil
+}
+
+func (t *TradeStatsTracker) persist(s TradeStats) error {
+	_, err := t.db.Exec(`
+		INSERT INTO strategy_stats_snapshots (
+			strategy, sample_size, sharpe, sortino, profit_factor,
+			avg_claimed_edge, avg_realized_edge,
+			current_win_streak, current_loss_streak, max_win_streak, max_loss_streak,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(strategy) DO UPDATE SET
+			sample_size = excluded.sample_size,
+			sharpe = excluded.sharpe,
+			sortino = excluded.sortino,
+			profit_factor = excluded.profit_factor,
+			avg_claimed_edge = excluded.avg_claimed_edge,
+			avg_realized_edge = excluded.avg_realized_edge,
+			current_win_streak = excluded.current_win_streak,
+			current_loss_streak = excluded.current_loss_streak,
+			max_win_streak = excluded.max_win_streak,
+			max_loss_streak = excluded.max_loss_streak,
+			updated_at = excluded.updated_at`,
+		s.Strategy, s.SampleSize, s.Sharpe, s.Sortino, s.ProfitFactor,
+		s.AvgClaimedEdge, s.AvgRealizedEdge,
+		s.CurrentWinStreak, s.CurrentLossStreak, s.MaxWinStreak, s.MaxLossStreak,
+	)
+	if err != nil {
+		return fmt.Errorf("upserting strategy_stats_snapshots: %w", err)
+	}
+	return nil
+}
+
+// Load reads strategyName's persisted TradeStats, returning ok=false if
+// Compute has never run for it.
+func (t *TradeStatsTracker) Load(strategyName string) (TradeStats, bool, error) {
+	row := t.db.QueryRow(`
+		SELECT strategy, sample_size, sharpe, sortino, profit_factor,
+		       avg_claimed_edge, avg_realized_edge,
+		       current_win_streak, current_loss_streak, max_win_streak, max_loss_streak
+		FROM strategy_stats_snapshots WHERE strategy = ?`, strategyName)
+
+	var s TradeStats
+	err := row.Scan(&s.Strategy, &s.SampleSize, &s.Sharpe, &s.Sortino, &s.ProfitFactor,
+		&s.AvgClaimedEdge, &s.AvgRealizedEdge,
+		&s.CurrentWinStreak, &s.CurrentLossStreak, &s.MaxWinStreak, &s.MaxLossStreak)
+	if err == sql.ErrNoRows {
+		return TradeStats{}, false, nil
+	}
+	if err != nil {
+		return TradeStats{}, false, err
+	}
+	return s, true, nil
+}
+
+// StrategyMultiplier returns the fractional-Kelly scaling factor
+// strategyName's fresh signals should be sized by, derived from its
+// persisted TradeStats: 0 if its profit factor is below 1.0 (losing money
+// net of wins), otherwise realized-edge/claimed-edge accuracy, capped at
+// 1.0 so an under-claiming strategy is never scaled up. Returns 1.0 (no
+// adjustment) if Compute hasn't run yet or the sample is smaller than
+// minSample. Satisfies strategy.PerformanceStats.
+func (t *TradeStatsTracker) StrategyMultiplier(strategyName string) float64 {
+	stats, ok, err := t.Load(strategyName)
+	if err != nil {
+		slog.Warn("trade stats: failed to load multiplier, leaving signals unscaled", "strategy", strategyName, "error", err)
+		return 1.0
+	}
+	if !ok || stats.SampleSize < t.minSample {
+		return 1.0
+	}
+	if stats.ProfitFactor < 1.0 {
+		return 0
+	}
+	if stats.AvgClaimedEdge <= 0 || stats.AvgRealizedEdge >= stats.AvgClaimedEdge {
+		return 1.0
+	}
+	return stats.AvgRealizedEdge / stats.AvgClaimedEdge
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func stddev(xs []float64, m float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sumSq float64
+	for _, x := range xs {
+		d := x - m
+		sumSq += d * d
+	}
+	return math.Sqrt(sumSq / float64(len(xs)))
+}
+
+// minStddev is the standard-deviation floor below which sharpeRatio and sortinoRatio
+// treat returns as constant and report 0 rather than dividing by a
+// near-zero standard deviation. Floating-point mean/variance arithmetic
+// leaves a residual of this order even for truly constant inputs, so an
+// exact sd == 0 check doesn't catch them.
+const minStddev = 1e-12
+
+// sharpeRatio is the mean per-bet return over its standard deviation, a
+// simple (non-annualized) risk-adjusted return measure appropriate for a
+// fixed batch of historical bets rather than a time series.
+func sharpeRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	m := mean(returns)
+	sd := stddev(returns, m)
+	if sd < minStddev {
+		return 0
+	}
+	return m / sd
+}
+
+// sortinoRatio is like sharpeRatio but only penalizes downside volatility
+// (negative returns), rewarding strategies whose variance comes from upside.
+func sortinoRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	m := mean(returns)
+
+	var downside []float64
+	for _, r := range returns {
+		if r < 0 {
+			downside = append(downside, r)
+		}
+	}
+	if len(downside) == 0 {
+		return 0
+	}
+	dd := stddev(downside, 0)
+	if dd < minStddev {
+		return 0
+	}
+	return m / dd
+}
@@ -0,0 +1,149 @@
+package performance
+
+import (
+	"database/sql"
+	"testing"
+
+	"hyperkaehler/internal/db"
+)
+
+func newTestTradeStatsDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func insertResolvedBet(t *testing.T, database *sql.DB, marketID, strategy, outcome string, amount, expectedProb, marketProb, pnl float64) {
+	t.Helper()
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES (?, 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')
+		ON CONFLICT(id) DO NOTHING`, marketID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction, resolved, pnl, resolved_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0.25, 1, ?, datetime('now'))`,
+		marketID, strategy, outcome, amount, expectedProb, marketProb, pnl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTradeStatsTracker_ComputeAndPersist(t *testing.T) {
+	database := newTestTradeStatsDB(t)
+
+	// Two winning bets and one loss, each claiming an edge of 0.10; the
+	// realized edge ((5+5-20)/300, about -0.03) falls short of the claim.
+	insertResolvedBet(t, database, "m1", "mispricing", "YES", 100, 0.60, 0.50, 5)
+	insertResolvedBet(t, database, "m2", "mispricing", "YES", 100, 0.60, 0.50, 5)
+	insertResolvedBet(t, database, "m3", "mispricing", "YES", 100, 0.60, 0.50, -20)
+
+	tracker := NewTradeStatsTracker(database, 0, 1)
+	all, err := tracker.Compute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("expected stats for 1 strategy, got %d", len(all))
+	}
+
+	stats := all[0]
+	if stats.Strategy != "mispricing" {
+		t.Errorf("expected strategy 'mispricing', got %s", stats.Strategy)
+	}
+	if stats.SampleSize != 3 {
+		t.Errorf("expected sample size 3, got %d", stats.SampleSize)
+	}
+	if stats.AvgClaimedEdge <= 0 {
+		t.Errorf("expected positive claimed edge, got %f", stats.AvgClaimedEdge)
+	}
+
+	loaded, ok, err := tracker.Load("mispricing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected persisted stats to be loadable")
+	}
+	if loaded.SampleSize != stats.SampleSize || loaded.ProfitFactor != stats.ProfitFactor {
+		t.Errorf("expected loaded stats to match computed stats, got %+v vs %+v", loaded, stats)
+	}
+}
+
+func TestTradeStatsTracker_StrategyMultiplier_InsufficientSampleIsNoOp(t *testing.T) {
+	database := newTestTradeStatsDB(t)
+	insertResolvedBet(t, database, "m1", "arbitrage", "YES", 100, 0.60, 0.50, 5)
+
+	tracker := NewTradeStatsTracker(database, 0, 10) // needs 10, only 1 bet exists
+	if _, err := tracker.Compute(); err != nil {
+		t.Fatal(err)
+	}
+
+	mult := tracker.StrategyMultiplier("arbitrage")
+	if mult != 1.0 {
+		t.Errorf("expected multiplier 1.0 below min sample, got %f", mult)
+	}
+}
+
+func TestTradeStatsTracker_StrategyMultiplier_LosingProfitFactorZeroesOut(t *testing.T) {
+	database := newTestTradeStatsDB(t)
+	for i := 0; i < 5; i++ {
+		insertResolvedBet(t, database, "m"+string(rune('1'+i)), "arbitrage", "YES", 100, 0.60, 0.50, -20)
+	}
+
+	tracker := NewTradeStatsTracker(database, 0, 1)
+	if _, err := tracker.Compute(); err != nil {
+		t.Fatal(err)
+	}
+
+	mult := tracker.StrategyMultiplier("arbitrage")
+	if mult != 0 {
+		t.Errorf("expected multiplier 0 when profit factor below 1.0, got %f", mult)
+	}
+}
+
+func TestTradeStatsTracker_StrategyMultiplier_ScalesByEdgeAccuracy(t *testing.T) {
+	database := newTestTradeStatsDB(t)
+	// Claimed edge is 0.10 (0.60 - 0.50) each time; realized edge (pnl/amount)
+	// averages to 0.05, half the claim, across a winning (profit factor > 1)
+	// sample.
+	for i := 0; i < 4; i++ {
+		insertResolvedBet(t, database, "m"+string(rune('1'+i)), "arbitrage", "YES", 100, 0.60, 0.50, 5)
+	}
+
+	tracker := NewTradeStatsTracker(database, 0, 1)
+	if _, err := tracker.Compute(); err != nil {
+		t.Fatal(err)
+	}
+
+	mult := tracker.StrategyMultiplier("arbitrage")
+	wantMult := 0.05 / 0.10
+	if diff := mult - wantMult; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected multiplier %f (realized/claimed edge), got %f", wantMult, mult)
+	}
+}
+
+func TestSharpeRatio_ZeroVarianceIsZero(t *testing.T) {
+	returns := []float64{0.1, 0.1, 0.1}
+	if got := sharpeRatio(returns); got != 0 {
+		t.Errorf("expected sharpe 0 for zero-variance returns, got %f", got)
+	}
+}
+
+func TestSortinoRatio_NegligibleDownsideIsZero(t *testing.T) {
+	// Downside deviation is measured from a 0 target, so near-zero (rather
+	// than merely constant) downside returns are what trips the epsilon
+	// guard — e.g. floating-point noise left over from a larger computation
+	// upstream, not a real loss worth penalizing.
+	returns := []float64{0.2, -1e-13, -1e-13}
+	if got := sortinoRatio(returns); got != 0 {
+		t.Errorf("expected sortino 0 for negligible downside returns, got %f", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Package retry provides exponential backoff with jitter for transient
+// failures talking to the Manifold API, modeled on cenkalti/backoff's
+// ExponentialBackOff semantics but trimmed to what this bot needs.
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// Backoff is an exponential retry schedule: each attempt's delay is
+// InitialInterval * Multiplier^attempt, capped at MaxInterval and jittered
+// by +/-RandomizationFactor, until MaxElapsedTime has passed since the
+// first attempt.
+type Backoff struct {
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	MaxElapsedTime      time.Duration
+}
+
+// GeneralBackoff is the default policy for operations that can tolerate a
+// slow or flaky API without stalling the caller badly: market scans and
+// portfolio refreshes, which already run on their own scheduler tick.
+func GeneralBackoff() Backoff {
+	return Backoff{
+		InitialInterval:     500 * time.Millisecond,
+		MaxInterval:         30 * time.Second,
+		Multiplier:          2.0,
+		RandomizationFactor: 0.5,
+		MaxElapsedTime:      2 * time.Minute,
+	}
+}
+
+// LiteBackoff is for latency-sensitive calls on the hot trading-cycle path
+// (placing a single bet), where a long retry loop would stall the whole
+// cycle: a handful of quick attempts over a few seconds before giving up to
+// executeSingle's own failure handling.
+func LiteBackoff() Backoff {
+	return Backoff{
+		InitialInterval:     200 * time.Millisecond,
+		MaxInterval:         2 * time.Second,
+		Multiplier:          2.0,
+		RandomizationFactor: 0.3,
+		MaxElapsedTime:      8 * time.Second,
+	}
+}
+
+// nextDelay returns the jittered delay for the given 0-indexed attempt,
+// capped at MaxInterval.
+func (b Backoff) nextDelay(attempt int) time.Duration {
+	raw := float64(b.InitialInterval) * math.Pow(b.Multiplier, float64(attempt))
+	if raw > float64(b.MaxInterval) {
+		raw = float64(b.MaxInterval)
+	}
+	if b.RandomizationFactor > 0 {
+		delta := raw * b.RandomizationFactor
+		raw = raw - delta + rand.Float64()*2*delta
+	}
+	if raw < 0 {
+		raw = 0
+	}
+	return time.Duration(raw)
+}
+
+// Retryable classifies whether an error from a failed attempt should be
+// retried. Callers that need to short-circuit on a specific permanent
+// error (e.g. executor.go's blacklist on a resolved market) rely on the
+// non-retryable case being returned to them immediately, undelayed.
+type Retryable func(error) bool
+
+// Do runs operation, retrying on b's schedule while isRetryable(err) is
+// true, until it succeeds, isRetryable returns false, or MaxElapsedTime
+// would be exceeded by the next delay. name identifies the operation in the
+// retry log.
+func Do(name string, b Backoff, isRetryable Retryable, operation func() error) error {
+	start := time.Now()
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = operation()
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) {
+			return err
+		}
+
+		delay := b.nextDelay(attempt)
+		if time.Since(start)+delay > b.MaxElapsedTime {
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, attempt+1, err)
+		}
+
+		slog.Warn("retrying after transient error",
+			"operation", name,
+			"attempt", attempt+1,
+			"next_delay", delay,
+			"error", err,
+		)
+		time.Sleep(delay)
+	}
+}
+
+// IsRetryableHTTPError reports whether err looks like a transient HTTP or
+// network failure (429, 5xx, timeout, connection reset) rather than a
+// permanent client error (400/403/404) or a domain-level rejection like a
+// resolved market, which callers typically want to short-circuit on rather
+// than retry.
+func IsRetryableHTTPError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"status 400", "status 403", "status 404", "resolved"} {
+		if strings.Contains(msg, marker) {
+			return false
+		}
+	}
+	for _, marker := range []string{
+		"status 429", "status 500", "status 502", "status 503", "status 504",
+		"timeout", "timed out", "connection reset", "eof",
+		"temporarily unavailable", "no such host",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo_SucceedsWithoutRetry(t *testing.T) {
+	calls := 0
+	err := Do("test", LiteBackoff(), IsRetryableHTTPError, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestDo_RetriesRetryableErrorThenSucceeds(t *testing.T) {
+	calls := 0
+	b := LiteBackoff()
+	b.InitialInterval = time.Millisecond
+	b.MaxInterval = 2 * time.Millisecond
+
+	err := Do("test", b, func(error) bool { return true }, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("status 503: temporarily unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDo_ShortCircuitsOnNonRetryableError(t *testing.T) {
+	calls := 0
+	wantErr := errors.New("status 404: not found")
+	err := Do("test", GeneralBackoff(), IsRetryableHTTPError, func() error {
+		calls++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected immediate non-retryable error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call (no retries), got %d", calls)
+	}
+}
+
+func TestDo_GivesUpAfterMaxElapsedTime(t *testing.T) {
+	b := Backoff{
+		InitialInterval:     time.Millisecond,
+		MaxInterval:         2 * time.Millisecond,
+		Multiplier:          2.0,
+		RandomizationFactor: 0,
+		MaxElapsedTime:      5 * time.Millisecond,
+	}
+
+	calls := 0
+	err := Do("test", b, func(error) bool { return true }, func() error {
+		calls++
+		return errors.New("status 503")
+	})
+	if err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls < 2 {
+		t.Errorf("expected at least 2 attempts before giving up, got %d", calls)
+	}
+}
+
+func TestIsRetryableHTTPError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", errors.New("status 429: too many requests"), true},
+		{"server error", errors.New("status 503: service unavailable"), true},
+		{"timeout", errors.New("request timed out"), true},
+		{"bad request", errors.New("status 400: bad request"), false},
+		{"forbidden", errors.New("status 403: forbidden"), false},
+		{"not found", errors.New("status 404: not found"), false},
+		{"resolved market", errors.New("bet rejected: market already resolved"), false},
+		{"unrecognized", errors.New("something went sideways"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryableHTTPError(tc.err); got != tc.want {
+				t.Errorf("IsRetryableHTTPError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package risk
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hyperkaehler/internal/config"
+)
+
+// CircuitBreaker is the pluggable interface Manager.CanTrade consults before
+// allowing new trades. BetHistoryCircuitBreaker below is the only
+// implementation today, deriving every trip condition by querying bot_bets
+// and risk_high_water_mark fresh on each Check — RecordSettlement exists so
+// an alternative implementation could instead track realized outcomes
+// in-memory as they happen, without a DB round-trip per check.
+type CircuitBreaker interface {
+	// Check evaluates all breaker conditions against current portfolio
+	// value, tripping the breaker if any threshold is crossed, and reports
+	// whether trading should halt and why.
+	Check(totalValue float64) (bool, string)
+	// RecordSettlement notifies the breaker that a bet on marketID resolved
+	// with realized pnl, so implementations that track state in memory
+	// rather than re-querying bot_bets can stay current.
+	RecordSettlement(marketID string, pnl float64)
+}
+
+// BetHistoryCircuitBreaker halts new trade execution when consecutive
+// losses, realized loss over a rolling window, or drawdown from a persisted
+// high-water mark cross configured thresholds. Scans and snapshots continue
+// to run while tripped; only signal sizing is gated (see Manager.CanTrade).
+// Strategies can also consult StrategyStatus directly from Evaluate to skip
+// or downscale their own signals, and a single strategy racking up
+// PerStrategyMaxConsecutiveLosses trips the breaker even when the portfolio
+// as a whole looks healthy.
+type BetHistoryCircuitBreaker struct {
+	db  *sql.DB
+	cfg config.CircuitBreakerConfig
+
+	trippedUntil time.Time
+	tripReason   string
+}
+
+func NewBetHistoryCircuitBreaker(db *sql.DB, cfg config.CircuitBreakerConfig) *BetHistoryCircuitBreaker {
+	return &BetHistoryCircuitBreaker{db: db, cfg: cfg}
+}
+
+// RecordSettlement is a no-op: every Check already re-derives its trip
+// conditions straight from bot_bets, so there's no in-memory state here for
+// a settlement to update. It exists to satisfy the CircuitBreaker interface
+// for callers that don't know which implementation they're holding.
+func (cb *BetHistoryCircuitBreaker) RecordSettlement(marketID string, pnl float64) {}
+
+// Tripped reports whether the breaker is currently halting trade, resetting
+// it first if the cool-off period has elapsed.
+func (cb *BetHistoryCircuitBreaker) Tripped() (bool, string) {
+	if !cb.cfg.Enabled {
+		return false, ""
+	}
+	if time.Now().Before(cb.trippedUntil) {
+		return true, cb.tripReason
+	}
+	if cb.tripReason != "" {
+		cb.resetLocally("cool-off elapsed")
+	}
+	return false, ""
+}
+
+// Check evaluates all breaker conditions against current portfolio value and
+// trips the breaker if any threshold is crossed. It returns the same result
+// as a subsequent call to Tripped.
+func (cb *BetHistoryCircuitBreaker) Check(totalValue float64) (bool, string) {
+	if !cb.cfg.Enabled {
+		return false, ""
+	}
+	if tripped, reason := cb.Tripped(); tripped {
+		return true, reason
+	}
+
+	if cb.cfg.MaxDrawdownPct > 0 {
+		peak, err := cb.loadOrUpdatePeak(totalValue)
+		if err != nil {
+			slog.Error("circuit breaker: failed to load high-water mark", "error", err)
+		} else if peak > 0 {
+			drawdown := (peak - totalValue) / peak
+			if drawdown >= cb.cfg.MaxDrawdownPct {
+				cb.trip(fmt.Sprintf("drawdown %.1f%% from peak %.2f exceeds limit %.1f%%",
+					drawdown*100, peak, cb.cfg.MaxDrawdownPct*100))
+				return true, cb.tripReason
+			}
+		}
+	}
+
+	if cb.cfg.MaxConsecutiveLosses > 0 {
+		losses, err := cb.consecutiveLosses()
+		if err != nil {
+			slog.Error("circuit breaker: failed to count consecutive losses", "error", err)
+		} else if losses >= cb.cfg.MaxConsecutiveLosses {
+			cb.trip(fmt.Sprintf("%d consecutive losing bets reached limit %d", losses, cb.cfg.MaxConsecutiveLosses))
+			return true, cb.tripReason
+		}
+	}
+
+	if cb.cfg.MaxLossMana > 0 && cb.cfg.LossWindow.Duration > 0 {
+		loss, err := cb.windowLoss(cb.cfg.LossWindow.Duration)
+		if err != nil {
+			slog.Error("circuit breaker: failed to compute rolling loss", "error", err)
+		} else if loss >= cb.cfg.MaxLossMana {
+			cb.trip(fmt.Sprintf("realized loss %.2f over %s exceeds limit %.2f",
+				loss, cb.cfg.LossWindow.Duration, cb.cfg.MaxLossMana))
+			return true, cb.tripReason
+		}
+	}
+
+	if cb.cfg.MaxBetsPerWindow > 0 && cb.cfg.RapidFireWindow.Duration > 0 {
+		count, err := cb.betsInWindow(cb.cfg.RapidFireWindow.Duration)
+		if err != nil {
+			slog.Error("circuit breaker: failed to count rapid-fire bets", "error", err)
+		} else if count > cb.cfg.MaxBetsPerWindow {
+			cb.trip(fmt.Sprintf("%d bets placed within %s exceeds limit %d",
+				count, cb.cfg.RapidFireWindow.Duration, cb.cfg.MaxBetsPerWindow))
+			return true, cb.tripReason
+		}
+	}
+
+	return false, ""
+}
+
+// Reset manually clears a tripped breaker, e.g. from an operator command.
+func (cb *BetHistoryCircuitBreaker) Reset() {
+	if cb.tripReason == "" {
+		return
+	}
+	cb.resetLocally("manual reset")
+}
+
+func (cb *BetHistoryCircuitBreaker) resetLocally(how string) {
+	slog.Info("circuit breaker reset", "previous_reason", cb.tripReason, "how", how)
+	_, err := cb.db.Exec(`
+		UPDATE circuit_breaker_events SET reset_at = datetime('now')
+		WHERE id = (SELECT id FROM circuit_breaker_events WHERE reset_at IS NULL ORDER BY id DESC LIMIT 1)`)
+	if err != nil {
+		slog.Error("circuit breaker: failed to record reset", "error", err)
+	}
+	cb.trippedUntil = time.Time{}
+	cb.tripReason = ""
+}
+
+func (cb *BetHistoryCircuitBreaker) trip(reason string) {
+	cb.tripForStrategy("", reason)
+}
+
+// tripForStrategy trips the breaker portfolio-wide, recording which
+// strategy (if any) triggered it. An empty strategyName marks a
+// portfolio-level trip condition (drawdown, rolling loss, global streak).
+func (cb *BetHistoryCircuitBreaker) tripForStrategy(strategyName, reason string) {
+	cb.tripReason = reason
+	cb.trippedUntil = time.Now().Add(cb.cfg.CoolOff.Duration)
+
+	slog.Warn("circuit breaker tripped", "reason", reason, "strategy", strategyName, "cool_off", cb.cfg.CoolOff.Duration)
+
+	_, err := cb.db.Exec(`
+		INSERT INTO circuit_breaker_events (reason, cool_off_seconds, strategy) VALUES (?, ?, ?)`,
+		reason, int(cb.cfg.CoolOff.Duration.Seconds()), strategyName,
+	)
+	if err != nil {
+		slog.Error("circuit breaker: failed to record trip event", "error", err)
+	}
+}
+
+func (cb *BetHistoryCircuitBreaker) loadOrUpdatePeak(totalValue float64) (float64, error) {
+	var peak float64
+	err := cb.db.QueryRow(`SELECT peak_balance FROM risk_high_water_mark WHERE id = 1`).Scan(&peak)
+	if err == sql.ErrNoRows {
+		_, err = cb.db.Exec(`INSERT INTO risk_high_water_mark (id, peak_balance) VALUES (1, ?)`, totalValue)
+		return totalValue, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	if totalValue > peak {
+		peak = totalValue
+		_, err = cb.db.Exec(`UPDATE risk_high_water_mark SET peak_balance = ?, updated_at = datetime('now') WHERE id = 1`, peak)
+	}
+	return peak, err
+}
+
+// consecutiveLosses counts the number of losing resolved bets most recently
+// settled, stopping at the first winner or break-even bet.
+func (cb *BetHistoryCircuitBreaker) consecutiveLosses() (int, error) {
+	rows, err := cb.db.Query(`
+		SELECT pnl FROM bot_bets
+		WHERE resolved = 1
+		ORDER BY resolved_at DESC
+		LIMIT 200`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return lossStreak(rows)
+}
+
+// consecutiveLossesForStrategy is consecutiveLosses scoped to bets placed by
+// a single strategy, used by StrategyStatus to trip on a losing streak
+// isolated to one strategy even while the portfolio as a whole looks fine.
+func (cb *BetHistoryCircuitBreaker) consecutiveLossesForStrategy(strategyName string) (int, error) {
+	rows, err := cb.db.Query(`
+		SELECT pnl FROM bot_bets
+		WHERE resolved = 1 AND strategy = ?
+		ORDER BY resolved_at DESC
+		LIMIT 200`, strategyName)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	return lossStreak(rows)
+}
+
+// lossStreak counts losing bets from the front of rows (ordered most-recent
+// first), stopping at the first winner, break-even bet, or exhausted rows.
+func lossStreak(rows *sql.Rows) (int, error) {
+	var streak int
+	for rows.Next() {
+		var pnl sql.NullFloat64
+		if err := rows.Scan(&pnl); err != nil {
+			return 0, err
+		}
+		if !pnl.Valid || pnl.Float64 >= 0 {
+			break
+		}
+		streak++
+	}
+	return streak, rows.Err()
+}
+
+// StrategyStatus reports whether strategyName should hold back or downscale
+// its signals: either because the breaker is already tripped portfolio-wide,
+// or because strategyName itself has just crossed
+// PerStrategyMaxConsecutiveLosses. The returned downscale factor is
+// cfg.SoftTripDownscaleFactor when tripped and 0 when not; a caller that
+// gets tripped=true, downscale=0 should skip evaluation entirely rather than
+// emit scaled-down signals.
+func (cb *BetHistoryCircuitBreaker) StrategyStatus(strategyName string) (tripped bool, downscale float64) {
+	if !cb.cfg.Enabled {
+		return false, 0
+	}
+
+	if tripped, _ := cb.Tripped(); !tripped && cb.cfg.PerStrategyMaxConsecutiveLosses > 0 {
+		losses, err := cb.consecutiveLossesForStrategy(strategyName)
+		if err != nil {
+			slog.Error("circuit breaker: failed to count per-strategy consecutive losses", "strategy", strategyName, "error", err)
+		} else if losses >= cb.cfg.PerStrategyMaxConsecutiveLosses {
+			cb.tripForStrategy(strategyName, fmt.Sprintf("strategy %q hit %d consecutive losing bets (limit %d)",
+				strategyName, losses, cb.cfg.PerStrategyMaxConsecutiveLosses))
+		}
+	}
+
+	if tripped, _ := cb.Tripped(); tripped {
+		return true, cb.cfg.SoftTripDownscaleFactor
+	}
+	return false, 0
+}
+
+// betsInWindow counts bets of any outcome placed within the given trailing
+// window, tripping the rapid-fire breaker regardless of whether those bets
+// have settled yet.
+func (cb *BetHistoryCircuitBreaker) betsInWindow(window time.Duration) (int, error) {
+	cutoff := time.Now().Add(-window).Format("2006-01-02 15:04:05")
+
+	var count int
+	err := cb.db.QueryRow(`
+		SELECT COUNT(*) FROM bot_bets WHERE placed_at >= ?`, cutoff,
+	).Scan(&count)
+	return count, err
+}
+
+// windowLoss returns the total realized loss (as a positive number) over the
+// given trailing window.
+func (cb *BetHistoryCircuitBreaker) windowLoss(window time.Duration) (float64, error) {
+	cutoff := time.Now().Add(-window).Format("2006-01-02 15:04:05")
+
+	var totalLoss sql.NullFloat64
+	err := cb.db.QueryRow(`
+		SELECT SUM(-pnl) FROM bot_bets
+		WHERE resolved = 1 AND pnl < 0 AND resolved_at >= ?`, cutoff,
+	).Scan(&totalLoss)
+	if err != nil {
+		return 0, err
+	}
+	if !totalLoss.Valid {
+		return 0, nil
+	}
+	return totalLoss.Float64, nil
+}
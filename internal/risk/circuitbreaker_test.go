@@ -0,0 +1,252 @@
+package risk
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
+)
+
+func newTestCircuitBreakerDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestCircuitBreaker_TripsOnConsecutiveLosses(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	for i := 0; i < 3; i++ {
+		marketID := "m" + string(rune('1'+i))
+		if _, err := database.Exec(`
+			INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+			VALUES (?, 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')`, marketID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.Exec(`
+			INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction, resolved, pnl, resolved_at)
+			VALUES (?, 'test', 'YES', 10, 0.6, 0.5, 0.25, 1, -10, datetime('now'))`, marketID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:              true,
+		MaxConsecutiveLosses: 3,
+		CoolOff:              config.Duration{Duration: time.Hour},
+	})
+
+	tripped, reason := cb.Check(1000)
+	if !tripped {
+		t.Fatal("expected breaker to trip on 3 consecutive losses")
+	}
+	if reason == "" {
+		t.Error("expected a trip reason")
+	}
+
+	tripped, _ = cb.Tripped()
+	if !tripped {
+		t.Error("expected breaker to remain tripped within cool-off")
+	}
+}
+
+func TestCircuitBreaker_TripsOnDrawdown(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:        true,
+		MaxDrawdownPct: 0.20,
+		CoolOff:        config.Duration{Duration: time.Hour},
+	})
+
+	// Establish a high-water mark of 1000.
+	if tripped, _ := cb.Check(1000); tripped {
+		t.Fatal("should not trip at the peak")
+	}
+
+	// Drop to 750: 25% drawdown exceeds the 20% limit.
+	tripped, reason := cb.Check(750)
+	if !tripped {
+		t.Fatal("expected breaker to trip on drawdown")
+	}
+	if reason == "" {
+		t.Error("expected a trip reason")
+	}
+}
+
+func TestCircuitBreaker_TripsOnRapidFire(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES ('m1', 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := database.Exec(`
+			INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction)
+			VALUES ('m1', 'test', 'YES', 10, 0.6, 0.5, 0.25)`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:          true,
+		MaxBetsPerWindow: 4,
+		RapidFireWindow:  config.Duration{Duration: time.Minute},
+		CoolOff:          config.Duration{Duration: time.Hour},
+	})
+
+	tripped, reason := cb.Check(1000)
+	if !tripped {
+		t.Fatal("expected breaker to trip on rapid-fire bet count")
+	}
+	if reason == "" {
+		t.Error("expected a trip reason")
+	}
+}
+
+func TestCircuitBreaker_DisabledNeverTrips(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{Enabled: false})
+
+	if tripped, _ := cb.Check(1); tripped {
+		t.Error("disabled breaker should never trip")
+	}
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:        true,
+		MaxDrawdownPct: 0.20,
+		CoolOff:        config.Duration{Duration: time.Hour},
+	})
+
+	cb.Check(1000)
+	cb.Check(700)
+	if tripped, _ := cb.Tripped(); !tripped {
+		t.Fatal("expected breaker to be tripped before reset")
+	}
+
+	cb.Reset()
+	if tripped, _ := cb.Tripped(); tripped {
+		t.Error("expected breaker to be clear after Reset")
+	}
+}
+
+func insertCircuitBreakerBet(t *testing.T, database *sql.DB, marketID, strategyName string, pnl float64) {
+	t.Helper()
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES (?, 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')
+		ON CONFLICT(id) DO NOTHING`, marketID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction, resolved, pnl, resolved_at)
+		VALUES (?, ?, 'YES', 10, 0.6, 0.5, 0.25, 1, ?, datetime('now'))`,
+		marketID, strategyName, pnl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCircuitBreaker_StrategyStatusLosingStrategyTripsPortfolioWide(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	for i := 0; i < 3; i++ {
+		insertCircuitBreakerBet(t, database, "m"+string(rune('1'+i)), "mispricing", -10)
+	}
+	insertCircuitBreakerBet(t, database, "m4", "arbitrage", 10)
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:                         true,
+		PerStrategyMaxConsecutiveLosses: 3,
+		CoolOff:                         config.Duration{Duration: time.Hour},
+	})
+
+	tripped, downscale := cb.StrategyStatus("mispricing")
+	if !tripped {
+		t.Fatal("expected mispricing to trip on 3 consecutive losses")
+	}
+	if downscale != 0 {
+		t.Errorf("expected a hard skip (downscale 0) with SoftTripDownscaleFactor unset, got %f", downscale)
+	}
+
+	// The trip is portfolio-wide once tripped, so arbitrage sees it too.
+	tripped, _ = cb.StrategyStatus("arbitrage")
+	if !tripped {
+		t.Error("expected the breaker to report tripped for any strategy once tripped")
+	}
+}
+
+func TestCircuitBreaker_StrategyStatusSoftTripDownscales(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	for i := 0; i < 3; i++ {
+		insertCircuitBreakerBet(t, database, "m"+string(rune('1'+i)), "mispricing", -10)
+	}
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:                         true,
+		PerStrategyMaxConsecutiveLosses: 3,
+		SoftTripDownscaleFactor:         0.5,
+		CoolOff:                         config.Duration{Duration: time.Hour},
+	})
+
+	tripped, downscale := cb.StrategyStatus("mispricing")
+	if !tripped {
+		t.Fatal("expected mispricing to trip on 3 consecutive losses")
+	}
+	if downscale != 0.5 {
+		t.Errorf("expected downscale factor 0.5, got %f", downscale)
+	}
+}
+
+func TestCircuitBreaker_StrategyStatusNotTrippedBelowThreshold(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+	insertCircuitBreakerBet(t, database, "m1", "mispricing", -10)
+
+	cb := NewBetHistoryCircuitBreaker(database, config.CircuitBreakerConfig{
+		Enabled:                         true,
+		PerStrategyMaxConsecutiveLosses: 3,
+		CoolOff:                         config.Duration{Duration: time.Hour},
+	})
+
+	tripped, _ := cb.StrategyStatus("mispricing")
+	if tripped {
+		t.Error("expected no trip below the per-strategy consecutive-loss threshold")
+	}
+}
+
+func TestBetHistoryCircuitBreaker_SatisfiesCircuitBreakerInterface(t *testing.T) {
+	var _ CircuitBreaker = NewBetHistoryCircuitBreaker(newTestCircuitBreakerDB(t), config.CircuitBreakerConfig{})
+}
+
+// fakeCircuitBreaker lets manager tests verify Manager.CanTrade consults
+// whatever CircuitBreaker implementation it's wired with, not specifically
+// BetHistoryCircuitBreaker.
+type fakeCircuitBreaker struct {
+	tripped bool
+	reason  string
+	settled []string
+}
+
+func (f *fakeCircuitBreaker) Check(totalValue float64) (bool, string) { return f.tripped, f.reason }
+func (f *fakeCircuitBreaker) RecordSettlement(marketID string, pnl float64) {
+	f.settled = append(f.settled, marketID)
+}
+
+func TestManager_CanTrade_ConsultsPluggableCircuitBreaker(t *testing.T) {
+	m := newTestManager(1000)
+	m.SetCircuitBreaker(&fakeCircuitBreaker{tripped: true, reason: "fake trip"})
+
+	if m.CanTrade() {
+		t.Error("expected CanTrade to respect a non-BetHistoryCircuitBreaker implementation's trip")
+	}
+}
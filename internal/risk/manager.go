@@ -1,8 +1,11 @@
 package risk
 
 import (
+	"database/sql"
+	"fmt"
 	"log/slog"
 	"math"
+	"time"
 
 	"hyperkaehler/internal/config"
 	"hyperkaehler/internal/strategy"
@@ -15,6 +18,11 @@ type Manager struct {
 	totalExposure   float64
 	marketExposure  map[string]float64 // marketID -> total mana wagered
 	peakBalance     float64
+	circuitBreaker  CircuitBreaker
+	lastAggregation []AggregatedSignal
+
+	db  *sql.DB
+	now func() time.Time
 }
 
 func NewManager(cfg config.RiskConfig, portfolio *Portfolio) *Manager {
@@ -22,6 +30,7 @@ func NewManager(cfg config.RiskConfig, portfolio *Portfolio) *Manager {
 		cfg:            cfg,
 		portfolio:      portfolio,
 		marketExposure: make(map[string]float64),
+		now:            time.Now,
 	}
 }
 
@@ -38,6 +47,17 @@ func (m *Manager) CanTrade() bool {
 		return false
 	}
 
+	if m.circuitBreaker != nil {
+		if tripped, reason := m.circuitBreaker.Check(m.portfolio.TotalValue); tripped {
+			slog.Warn("cannot trade: circuit breaker tripped", "reason", reason)
+			return false
+		}
+	}
+
+	if m.dailyBudgetExceeded() {
+		return false
+	}
+
 	// Update peak balance.
 	if m.portfolio.TotalValue > m.peakBalance {
 		m.peakBalance = m.portfolio.TotalValue
@@ -68,35 +88,36 @@ func (m *Manager) CanTrade() bool {
 	return true
 }
 
-// SizeSignals takes raw signals and returns sized, approved signals.
+// SizeSignals takes raw signals and returns sized, approved signals. Signals
+// sharing a MarketID are first netted into one signal by aggregateByMarket,
+// so multiple strategies betting the same market in the same cycle produce
+// a single sized bet rather than stepping on each other.
 func (m *Manager) SizeSignals(signals []strategy.Signal) []SizedSignal {
 	if !m.CanTrade() {
 		return nil
 	}
 
+	netted, aggregated := m.aggregateByMarket(signals)
+	m.lastAggregation = aggregated
+
 	// Track per-market exposure within this sizing pass.
 	cycleMarketExposure := make(map[string]float64)
 
-	sized := make([]SizedSignal, 0, len(signals))
-	for _, sig := range signals {
+	sized := make([]SizedSignal, 0, len(netted))
+	for _, sig := range netted {
 		amount := m.sizePosition(sig)
 
 		// Apply per-market exposure cap.
-		if m.cfg.MaxMarketExposurePct > 0 {
-			maxMarketExposure := m.cfg.MaxMarketExposurePct * m.portfolio.TotalValue
-			existingExposure := m.marketExposure[sig.MarketID] + cycleMarketExposure[sig.MarketID]
-			remaining := maxMarketExposure - existingExposure
-			if remaining <= 0 {
+		if amount > 0 {
+			capped := m.CapToMarketExposure(sig.MarketID, amount, cycleMarketExposure[sig.MarketID])
+			if capped <= 0 {
 				slog.Info("signal rejected: market exposure cap reached",
 					"market", sig.MarketID,
-					"existing_exposure", existingExposure,
-					"cap", maxMarketExposure,
+					"existing_exposure", m.marketExposure[sig.MarketID]+cycleMarketExposure[sig.MarketID],
 				)
 				continue
 			}
-			if amount > remaining {
-				amount = math.Floor(remaining)
-			}
+			amount = capped
 		}
 
 		if amount >= m.cfg.MinBetAmount {
@@ -117,6 +138,187 @@ func (m *Manager) SizeSignals(signals []strategy.Signal) []SizedSignal {
 	return sized
 }
 
+// AggregatedSignal is the per-(market,answer) outcome of netting every
+// strategy's SignalNumber together in aggregateByMarket, kept around on the
+// Manager for logging and backtest inspection via LastAggregation. It's
+// only populated for groups with more than one signal in a sizing pass.
+type AggregatedSignal struct {
+	MarketID      string
+	AnswerID      string
+	SignalNumber  float64 // weighted, TTL-decayed net of the group's SignalNumbers
+	Confidence    float64 // marketProb + SignalNumber*MaxEdge, clamped to (0,1)
+	StrategyCount int
+}
+
+// LastAggregation returns the per-(market,answer) netting detail from the
+// most recent SizeSignals call.
+func (m *Manager) LastAggregation() []AggregatedSignal {
+	return m.lastAggregation
+}
+
+// aggregateByMarket groups signals by (MarketID, AnswerID) — the same
+// granularity strategy.Aggregator nets at, so a MULTIPLE_CHOICE market's
+// distinct per-answer signals (e.g. Arbitrage's) stay distinct here too —
+// and nets any group of more than one signal into a single combined
+// signal. Each member's SignalNumber (falling back to an Edge/Outcome-
+// derived conviction via effectiveSignalNumber for strategies that don't
+// set it) is weighted by RiskConfig.StrategyWeights and a staleness decay
+// from signalWeight, then Confidence is derived from marketProb +
+// netSignalNumber*MaxEdge (clamped to (0,1)) and Outcome from its sign. A
+// group with a single signal passes through unchanged. Returns the netted
+// signals alongside the per-group aggregation detail for observability.
+func (m *Manager) aggregateByMarket(signals []strategy.Signal) ([]strategy.Signal, []AggregatedSignal) {
+	type groupKey struct {
+		marketID, answerID string
+	}
+	groups := make(map[groupKey][]strategy.Signal)
+	var order []groupKey
+	for _, sig := range signals {
+		key := groupKey{sig.MarketID, sig.AnswerID}
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], sig)
+	}
+
+	netted := make([]strategy.Signal, 0, len(signals))
+	var aggregated []AggregatedSignal
+	for _, key := range order {
+		group := groups[key]
+		if len(group) == 1 {
+			netted = append(netted, group[0])
+			continue
+		}
+
+		var weightedNumber, totalWeight, probSum float64
+		for _, sig := range group {
+			w := m.signalWeight(sig)
+			weightedNumber += w * m.effectiveSignalNumber(sig)
+			totalWeight += w
+			probSum += sig.MarketProb
+		}
+		if totalWeight <= 0 {
+			netted = append(netted, group...)
+			continue
+		}
+
+		signalNumber := weightedNumber / totalWeight
+		marketProb := probSum / float64(len(group))
+		confidence := clampConfidence(marketProb + signalNumber*m.cfg.MaxEdge)
+
+		outcome := "YES"
+		if signalNumber < 0 {
+			outcome = "NO"
+		}
+		edge := confidence - marketProb
+		if edge < 0 {
+			edge = -edge
+		}
+
+		netted = append(netted, strategy.Signal{
+			MarketID:   key.marketID,
+			AnswerID:   key.answerID,
+			Outcome:    outcome,
+			Confidence: confidence,
+			MarketProb: marketProb,
+			Edge:       edge,
+			Strategy:   "risk_aggregate",
+			Reason:     fmt.Sprintf("netted signal_number %.3f from %d strategies", signalNumber, len(group)),
+		})
+		aggregated = append(aggregated, AggregatedSignal{
+			MarketID:      key.marketID,
+			AnswerID:      key.answerID,
+			SignalNumber:  signalNumber,
+			Confidence:    confidence,
+			StrategyCount: len(group),
+		})
+
+		slog.Info("risk manager: netted signals for market",
+			"market", key.marketID,
+			"answer", key.answerID,
+			"signal_number", signalNumber,
+			"confidence", confidence,
+			"strategies", len(group),
+		)
+	}
+
+	return netted, aggregated
+}
+
+// signalWeight is sig's contribution to its group's netted SignalNumber:
+// its strategy's configured weight (default 1.0) scaled down as the signal
+// ages past RiskConfig.SignalTTL.
+func (m *Manager) signalWeight(sig strategy.Signal) float64 {
+	w := 1.0
+	if cw, ok := m.cfg.StrategyWeights[sig.Strategy]; ok {
+		w = cw
+	}
+	return w * m.staleness(sig.GeneratedAt)
+}
+
+// effectiveSignalNumber returns sig.SignalNumber if its strategy set one,
+// or derives a stand-in conviction from Edge/Outcome otherwise.
+// SignalNumber's zero value is indistinguishable from "unset" — the same
+// convention Signal.SizeMultiplier uses — and today no strategy populates
+// it, so without this fallback every multi-strategy market would net to a
+// zero SignalNumber and a zero-edge, unsized signal. The fallback direction
+// follows Outcome and its magnitude scales Edge against MaxEdge (the same
+// scale the netted SignalNumber is later projected back through), clamped
+// to [-1, 1].
+func (m *Manager) effectiveSignalNumber(sig strategy.Signal) float64 {
+	if sig.SignalNumber != 0 {
+		return sig.SignalNumber
+	}
+
+	direction := 1.0
+	if sig.Outcome == "NO" {
+		direction = -1.0
+	}
+
+	maxEdge := m.cfg.MaxEdge
+	if maxEdge <= 0 {
+		maxEdge = 1.0
+	}
+	magnitude := sig.Edge / maxEdge
+	if magnitude > 1 {
+		magnitude = 1
+	} else if magnitude < 0 {
+		magnitude = 0
+	}
+	return direction * magnitude
+}
+
+// staleness returns a linear decay factor in [0,1] for a signal generated
+// at t: 1.0 for a fresh or zero-value (unset) timestamp, decaying to 0 at
+// RiskConfig.SignalTTL. A SignalTTL of 0 disables decay entirely.
+func (m *Manager) staleness(t time.Time) float64 {
+	if t.IsZero() || m.cfg.SignalTTL.Duration <= 0 {
+		return 1.0
+	}
+	age := m.now().Sub(t)
+	if age <= 0 {
+		return 1.0
+	}
+	decay := 1.0 - float64(age)/float64(m.cfg.SignalTTL.Duration)
+	if decay < 0 {
+		return 0
+	}
+	return decay
+}
+
+// clampConfidence keeps a derived confidence strictly within (0, 1) so it
+// never produces a zero or saturated Kelly fraction downstream.
+func clampConfidence(c float64) float64 {
+	const epsilon = 1e-6
+	if c <= 0 {
+		return epsilon
+	}
+	if c >= 1 {
+		return 1 - epsilon
+	}
+	return c
+}
+
 func (m *Manager) sizePosition(sig strategy.Signal) float64 {
 	if sig.Edge < m.cfg.MinEdge {
 		return 0
@@ -168,6 +370,14 @@ func (m *Manager) sizePosition(sig strategy.Signal) float64 {
 		amount = remainingExposure
 	}
 
+	// Apply the strategy's relative size multiplier (e.g. market-making
+	// layers). 0 means unset, treated as no scaling.
+	sizeMultiplier := sig.SizeMultiplier
+	if sizeMultiplier <= 0 {
+		sizeMultiplier = 1.0
+	}
+	amount *= sizeMultiplier
+
 	// Don't bet more than we have.
 	if amount > m.portfolio.Balance {
 		amount = m.portfolio.Balance
@@ -187,16 +397,92 @@ func (m *Manager) sizePosition(sig strategy.Signal) float64 {
 func (m *Manager) RecordTrade(marketID string, amount float64) {
 	m.totalExposure += amount
 	m.marketExposure[marketID] += amount
+	m.recordDailyUsage(amount)
 }
 
-// Refresh updates the manager's state from the portfolio.
+// CapToMarketExposure bounds amount against RiskConfig.MaxMarketExposurePct
+// for marketID, on top of already-recorded exposure (via RecordTrade) and
+// extra — exposure already committed against marketID earlier in the same
+// pass but not yet recorded, e.g. hedging.Hedger tracking the primary
+// signal and any prior hedge legs it has already allocated onto the same
+// market. Returns 0 once the cap leaves no budget. A MaxMarketExposurePct
+// of 0 disables the cap and returns amount unchanged.
+func (m *Manager) CapToMarketExposure(marketID string, amount, extra float64) float64 {
+	if m.cfg.MaxMarketExposurePct <= 0 {
+		return amount
+	}
+	maxMarketExposure := m.cfg.MaxMarketExposurePct * m.portfolio.TotalValue
+	existingExposure := m.marketExposure[marketID] + extra
+	remaining := maxMarketExposure - existingExposure
+	if remaining <= 0 {
+		return 0
+	}
+	if amount > remaining {
+		amount = math.Floor(remaining)
+	}
+	return amount
+}
+
+// Refresh updates the manager's state from the portfolio. If SetDailyUsageDB
+// has wired a db, peakBalance is persisted across restarts in the same
+// risk_high_water_mark table CircuitBreaker uses for its own drawdown check
+// (both are tracking the same quantity: the high-water mark of
+// portfolio.TotalValue), and a row is appended to risk_state_history so
+// operators can chart the drawdown timeline rather than just the latest
+// value.
 func (m *Manager) Refresh() {
-	if m.portfolio.TotalValue > m.peakBalance {
+	if m.db != nil {
+		peak, err := m.loadOrUpdatePeak(m.portfolio.TotalValue)
+		if err != nil {
+			slog.Error("risk manager: failed to load persisted peak balance", "error", err)
+			if m.portfolio.TotalValue > m.peakBalance {
+				m.peakBalance = m.portfolio.TotalValue
+			}
+		} else {
+			m.peakBalance = peak
+		}
+	} else if m.portfolio.TotalValue > m.peakBalance {
 		m.peakBalance = m.portfolio.TotalValue
 	}
-	// Use the portfolio's investment value as total exposure â€” this is the actual
+
+	// Use the portfolio's investment value as total exposure — this is the actual
 	// amount of capital deployed in positions, fetched from the Manifold API.
 	m.totalExposure = m.portfolio.InvestmentValue
+
+	m.recordStateHistory()
+}
+
+func (m *Manager) loadOrUpdatePeak(totalValue float64) (float64, error) {
+	var peak float64
+	err := m.db.QueryRow(`SELECT peak_balance FROM risk_high_water_mark WHERE id = 1`).Scan(&peak)
+	if err == sql.ErrNoRows {
+		_, err = m.db.Exec(`INSERT INTO risk_high_water_mark (id, peak_balance) VALUES (1, ?)`, totalValue)
+		return totalValue, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	if totalValue > peak {
+		peak = totalValue
+		_, err = m.db.Exec(`UPDATE risk_high_water_mark SET peak_balance = ?, updated_at = datetime('now') WHERE id = 1`, peak)
+	}
+	return peak, err
+}
+
+// recordStateHistory appends a risk_state_history row with the manager's
+// current peak balance and exposure, a no-op without a wired db.
+func (m *Manager) recordStateHistory() {
+	if m.db == nil {
+		return
+	}
+	_, err := m.db.Exec(`
+		INSERT INTO risk_state_history (peak_balance, total_exposure, total_value)
+		VALUES (?, ?, ?)`,
+		m.peakBalance, m.totalExposure, m.portfolio.TotalValue,
+	)
+	if err != nil {
+		slog.Error("risk manager: failed to record state history", "error", err)
+	}
 }
 
 // SetExposure sets the current total exposure (from active bets/orders).
@@ -208,3 +494,116 @@ func (m *Manager) SetExposure(exposure float64) {
 func (m *Manager) SetMarketExposure(exposure map[string]float64) {
 	m.marketExposure = exposure
 }
+
+// SetCircuitBreaker wires a CircuitBreaker into CanTrade. A nil breaker
+// disables the check entirely. Any implementation of the interface works,
+// not just BetHistoryCircuitBreaker.
+func (m *Manager) SetCircuitBreaker(cb CircuitBreaker) {
+	m.circuitBreaker = cb
+}
+
+// SetDailyUsageDB wires a DB that Manager uses to persist daily mana/bet/
+// volume usage across restarts. A nil db disables the daily budget checks.
+func (m *Manager) SetDailyUsageDB(db *sql.DB) {
+	m.db = db
+}
+
+// SetClock overrides the Manager's notion of "now", used to control day
+// boundaries and resets in tests. Defaults to time.Now.
+func (m *Manager) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// DailyUsage is the bot's accumulated spend/volume for a given UTC usage day.
+type DailyUsage struct {
+	Date      string
+	ManaSpent float64
+	BetsCount int
+	Volume    float64
+}
+
+// usageDate returns the UTC calendar date a given instant falls into for
+// daily budget purposes, shifted by DailyResetHourUTC so the "day" rolls
+// over at that hour instead of always at midnight.
+func (m *Manager) usageDate(t time.Time) string {
+	return t.UTC().Add(-time.Duration(m.cfg.DailyResetHourUTC) * time.Hour).Format("2006-01-02")
+}
+
+func (m *Manager) loadDailyUsage() (DailyUsage, error) {
+	date := m.usageDate(m.now())
+	usage := DailyUsage{Date: date}
+	if m.db == nil {
+		return usage, nil
+	}
+
+	err := m.db.QueryRow(`
+		SELECT mana_spent, bets_count, volume FROM daily_usage WHERE usage_date = ?`, date,
+	).Scan(&usage.ManaSpent, &usage.BetsCount, &usage.Volume)
+	if err == sql.ErrNoRows {
+		return usage, nil
+	}
+	return usage, err
+}
+
+// dailyBudgetExceeded reports whether any configured daily cap has already
+// been reached for today's usage, logging a warning once any cap crosses
+// 80% consumed.
+func (m *Manager) dailyBudgetExceeded() bool {
+	if m.cfg.DailyManaBudget <= 0 && m.cfg.DailyMaxBets <= 0 && m.cfg.DailyMaxVolume <= 0 {
+		return false
+	}
+
+	usage, err := m.loadDailyUsage()
+	if err != nil {
+		slog.Error("failed to load daily usage", "error", err)
+		return false
+	}
+
+	if m.portfolio != nil {
+		m.portfolio.DailyUsage = usage
+	}
+
+	warnIfNear := func(label string, used, limit float64) {
+		if limit > 0 && used/limit >= 0.8 && used < limit {
+			slog.Warn("daily budget nearing limit", "budget", label, "used", used, "limit", limit)
+		}
+	}
+	warnIfNear("mana", usage.ManaSpent, m.cfg.DailyManaBudget)
+	warnIfNear("bets", float64(usage.BetsCount), float64(m.cfg.DailyMaxBets))
+	warnIfNear("volume", usage.Volume, m.cfg.DailyMaxVolume)
+
+	if m.cfg.DailyManaBudget > 0 && usage.ManaSpent >= m.cfg.DailyManaBudget {
+		slog.Warn("trading halted: daily mana budget reached", "spent", usage.ManaSpent, "budget", m.cfg.DailyManaBudget)
+		return true
+	}
+	if m.cfg.DailyMaxBets > 0 && usage.BetsCount >= m.cfg.DailyMaxBets {
+		slog.Warn("trading halted: daily bet count reached", "bets", usage.BetsCount, "limit", m.cfg.DailyMaxBets)
+		return true
+	}
+	if m.cfg.DailyMaxVolume > 0 && usage.Volume >= m.cfg.DailyMaxVolume {
+		slog.Warn("trading halted: daily volume cap reached", "volume", usage.Volume, "limit", m.cfg.DailyMaxVolume)
+		return true
+	}
+	return false
+}
+
+// recordDailyUsage accumulates a placed trade's mana amount into today's
+// usage row, creating it if this is the first trade of the day.
+func (m *Manager) recordDailyUsage(amount float64) {
+	if m.db == nil {
+		return
+	}
+	date := m.usageDate(m.now())
+	_, err := m.db.Exec(`
+		INSERT INTO daily_usage (usage_date, mana_spent, bets_count, volume)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT(usage_date) DO UPDATE SET
+			mana_spent = mana_spent + excluded.mana_spent,
+			bets_count = bets_count + 1,
+			volume = volume + excluded.volume`,
+		date, amount, amount,
+	)
+	if err != nil {
+		slog.Error("failed to record daily usage", "error", err)
+	}
+}
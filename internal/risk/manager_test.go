@@ -2,6 +2,7 @@ package risk
 
 import (
 	"testing"
+	"time"
 
 	"hyperkaehler/internal/config"
 	"hyperkaehler/internal/strategy"
@@ -21,6 +22,7 @@ func newTestManager(balance float64) *Manager {
 		MaxDrawdownPct:       0.20,
 		MinBetAmount:         1.0,
 		MinEdge:              0.05,
+		MaxEdge:              0.20,
 	}
 	return NewManager(cfg, portfolio)
 }
@@ -179,3 +181,189 @@ func TestSizeSignals_MarketExposureCap(t *testing.T) {
 		t.Errorf("market-2 should have higher amount than capped market-1, got %f vs %f", market2Amount, market1Amount)
 	}
 }
+
+func goodSignal(marketID string) strategy.Signal {
+	return strategy.Signal{
+		MarketID:   marketID,
+		Outcome:    "YES",
+		Confidence: 0.70,
+		MarketProb: 0.50,
+		Edge:       0.20,
+		Strategy:   "test",
+	}
+}
+
+func TestCanTrade_DailyManaBudgetReached(t *testing.T) {
+	m := newTestManager(2300)
+	database := newTestCircuitBreakerDB(t)
+	m.SetDailyUsageDB(database)
+	m.cfg.DailyManaBudget = 100
+
+	fixedNow := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return fixedNow })
+
+	m.RecordTrade("market-1", 100)
+
+	if m.CanTrade() {
+		t.Error("expected CanTrade to return false once daily mana budget is reached")
+	}
+}
+
+func TestCanTrade_DailyMaxBetsReached(t *testing.T) {
+	m := newTestManager(2300)
+	database := newTestCircuitBreakerDB(t)
+	m.SetDailyUsageDB(database)
+	m.cfg.DailyMaxBets = 2
+
+	fixedNow := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return fixedNow })
+
+	m.RecordTrade("market-1", 10)
+	m.RecordTrade("market-2", 10)
+
+	if m.CanTrade() {
+		t.Error("expected CanTrade to return false once daily bet count is reached")
+	}
+}
+
+func TestCanTrade_DailyUsageResetsAtDayBoundary(t *testing.T) {
+	m := newTestManager(2300)
+	database := newTestCircuitBreakerDB(t)
+	m.SetDailyUsageDB(database)
+	m.cfg.DailyManaBudget = 100
+
+	day1 := time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return day1 })
+	m.RecordTrade("market-1", 100)
+	if m.CanTrade() {
+		t.Fatal("expected CanTrade to return false after exhausting day 1's budget")
+	}
+
+	day2 := time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return day2 })
+	if !m.CanTrade() {
+		t.Error("expected CanTrade to return true after crossing the UTC day boundary")
+	}
+}
+
+func TestSizeSignals_RejectedWhenDailyVolumeCapReached(t *testing.T) {
+	m := newTestManager(2300)
+	database := newTestCircuitBreakerDB(t)
+	m.SetDailyUsageDB(database)
+	m.cfg.DailyMaxVolume = 50
+
+	fixedNow := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.SetClock(func() time.Time { return fixedNow })
+
+	m.RecordTrade("market-1", 50)
+
+	sized := m.SizeSignals([]strategy.Signal{goodSignal("market-2")})
+	if len(sized) != 0 {
+		t.Errorf("expected 0 sized signals once daily volume cap is reached, got %d", len(sized))
+	}
+}
+
+func TestRefresh_PersistsPeakBalanceAcrossRestarts(t *testing.T) {
+	database := newTestCircuitBreakerDB(t)
+
+	m1 := newTestManager(3000)
+	m1.SetDailyUsageDB(database)
+	m1.Refresh()
+
+	// A fresh Manager (simulating a restart) starts with no in-memory peak,
+	// but should recover the persisted high-water mark even though its
+	// current balance is lower.
+	m2 := newTestManager(2300)
+	m2.SetDailyUsageDB(database)
+	m2.Refresh()
+
+	if m2.peakBalance != 3000 {
+		t.Errorf("expected peak balance to survive restart as 3000, got %f", m2.peakBalance)
+	}
+
+	var rows int
+	if err := database.QueryRow(`SELECT COUNT(*) FROM risk_state_history`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("expected a risk_state_history row per Refresh call, got %d", rows)
+	}
+}
+
+func TestSizeSignals_DistinctAnswersOnSameMarketNotNetted(t *testing.T) {
+	m := newTestManager(2300)
+	// Two different answers of the same MULTIPLE_CHOICE market, each with
+	// its own edge — e.g. Arbitrage betting NO on two overpriced answers.
+	// These must stay distinct, not collapse into one zero-edge signal.
+	signals := []strategy.Signal{
+		{
+			MarketID:   "mc-market",
+			AnswerID:   "answer-a",
+			Outcome:    "NO",
+			Confidence: 0.80,
+			MarketProb: 0.60,
+			Edge:       0.20,
+			Strategy:   "arbitrage",
+		},
+		{
+			MarketID:   "mc-market",
+			AnswerID:   "answer-b",
+			Outcome:    "NO",
+			Confidence: 0.75,
+			MarketProb: 0.55,
+			Edge:       0.20,
+			Strategy:   "arbitrage",
+		},
+	}
+
+	sized := m.SizeSignals(signals)
+	if len(sized) != 2 {
+		t.Fatalf("expected both answers sized independently, got %d", len(sized))
+	}
+	for _, s := range sized {
+		if s.Amount < 1 {
+			t.Errorf("expected answer %s to size above zero, got %f", s.Signal.AnswerID, s.Amount)
+		}
+	}
+}
+
+func TestSizeSignals_NetsSameMarketAnswerAcrossStrategies(t *testing.T) {
+	m := newTestManager(2300)
+	// Two strategies betting the same (market, answer) without ever setting
+	// SignalNumber should still net to a non-zero, sizeable signal via the
+	// Edge/Outcome-derived fallback, rather than netting to zero edge.
+	signals := []strategy.Signal{
+		{
+			MarketID:   "m1",
+			Outcome:    "YES",
+			Confidence: 0.70,
+			MarketProb: 0.50,
+			Edge:       0.20,
+			Strategy:   "timedecay",
+		},
+		{
+			MarketID:   "m1",
+			Outcome:    "YES",
+			Confidence: 0.65,
+			MarketProb: 0.50,
+			Edge:       0.15,
+			Strategy:   "mispricing",
+		},
+	}
+
+	sized := m.SizeSignals(signals)
+	if len(sized) != 1 {
+		t.Fatalf("expected the two strategies to net into 1 sized signal, got %d", len(sized))
+	}
+	if sized[0].Amount < 1 {
+		t.Errorf("expected a non-zero netted amount, got %f", sized[0].Amount)
+	}
+
+	agg := m.LastAggregation()
+	if len(agg) != 1 {
+		t.Fatalf("expected 1 aggregation record, got %d", len(agg))
+	}
+	if agg[0].SignalNumber <= 0 {
+		t.Errorf("expected a positive netted signal number (both strategies agree YES), got %f", agg[0].SignalNumber)
+	}
+}
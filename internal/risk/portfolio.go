@@ -1,10 +1,14 @@
 package risk
 
 import (
+	"database/sql"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jonnyspicer/mango"
+
+	"hyperkaehler/internal/retry"
 )
 
 // Portfolio tracks the bot's current balance and investment state.
@@ -14,15 +18,38 @@ type Portfolio struct {
 	InvestmentValue float64
 	TotalValue      float64
 	UserID          string
+
+	db            *sql.DB
+	fixer         *PortfolioFixer
+	fixTolerance  float64 // fractional disagreement between DB and API investment value that triggers a fix
+
+	// DailyUsage is the bot's accumulated spend/volume for today, kept in
+	// sync by risk.Manager's daily budget check in CanTrade, which runs only
+	// when a daily cap is configured.
+	DailyUsage DailyUsage
 }
 
 func NewPortfolio(client *mango.Client) *Portfolio {
 	return &Portfolio{client: client}
 }
 
+// SetFixer wires a PortfolioFixer that Refresh invokes opportunistically
+// when the DB's recorded investment value disagrees with the API's by more
+// than tolerance (a fraction of the API value, e.g. 0.10 for 10%).
+func (p *Portfolio) SetFixer(db *sql.DB, fixer *PortfolioFixer, tolerance float64) {
+	p.db = db
+	p.fixer = fixer
+	p.fixTolerance = tolerance
+}
+
 // Refresh fetches the latest balance and portfolio data from the API.
 func (p *Portfolio) Refresh() error {
-	user, err := p.client.GetAuthenticatedUser()
+	var user *mango.User
+	err := retry.Do("get_authenticated_user", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+		var userErr error
+		user, userErr = p.client.GetAuthenticatedUser()
+		return userErr
+	})
 	if err != nil {
 		return fmt.Errorf("getting authenticated user: %w", err)
 	}
@@ -33,7 +60,12 @@ func (p *Portfolio) Refresh() error {
 	p.UserID = user.Id
 	p.Balance = user.Balance
 
-	portfolio, err := p.client.GetUserPortfolio(user.Id)
+	var portfolio *mango.LivePortfolioMetrics
+	err = retry.Do("get_user_portfolio", retry.GeneralBackoff(), retry.IsRetryableHTTPError, func() error {
+		var portErr error
+		portfolio, portErr = p.client.GetUserPortfolio(user.Id)
+		return portErr
+	})
 	if err != nil {
 		// Non-fatal: we at least have the balance.
 		slog.Warn("failed to get portfolio", "error", err)
@@ -51,5 +83,53 @@ func (p *Portfolio) Refresh() error {
 		"invested", p.InvestmentValue,
 		"total", p.TotalValue,
 	)
+
+	p.maybeFix()
 	return nil
 }
+
+// maybeFix runs the PortfolioFixer if the DB's recorded investment value has
+// drifted from the API's by more than fixTolerance. This is a safety net
+// against DB wipes, config changes, or bot downtime leaving the local state
+// stale.
+func (p *Portfolio) maybeFix() {
+	if p.fixer == nil || p.db == nil || p.fixTolerance <= 0 {
+		return
+	}
+
+	var dbInvested sql.NullFloat64
+	err := p.db.QueryRow(`SELECT SUM(amount) FROM bot_bets WHERE resolved = 0`).Scan(&dbInvested)
+	if err != nil {
+		slog.Warn("portfolio: failed to read recorded investment value", "error", err)
+		return
+	}
+
+	recorded := dbInvested.Float64
+	if p.InvestmentValue <= 0 {
+		return
+	}
+
+	deviation := (p.InvestmentValue - recorded) / p.InvestmentValue
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	if deviation <= p.fixTolerance {
+		return
+	}
+
+	slog.Warn("portfolio: DB investment value disagrees with API, running fixer",
+		"db_recorded", recorded,
+		"api_reported", p.InvestmentValue,
+		"deviation", deviation,
+	)
+
+	summary, err := p.fixer.Fix(time.Now().AddDate(0, -3, 0))
+	if err != nil {
+		slog.Error("portfolio: opportunistic fix failed", "error", err)
+		return
+	}
+	slog.Info("portfolio: opportunistic fix complete",
+		"bets_processed", summary.BetsProcessed,
+		"markets_rebuilt", summary.MarketsBuilt,
+	)
+}
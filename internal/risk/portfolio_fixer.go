@@ -0,0 +1,183 @@
+package risk
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jonnyspicer/mango"
+)
+
+// PortfolioFixer rebuilds bot_bets in the local DB from the authenticated
+// user's Manifold bet history, one row per bet. Per-market net shares, cost
+// and fees are accumulated only in memory to fill the FixSummary; no
+// per-market position, average-cost, realized-PnL or fee rows are written
+// to the DB. It mirrors bbgo's ProfitFixer: a recovery path for
+// when the persisted state is missing or has drifted from the exchange.
+type PortfolioFixer struct {
+	client *mango.Client
+	db     *sql.DB
+}
+
+func NewPortfolioFixer(client *mango.Client, db *sql.DB) *PortfolioFixer {
+	return &PortfolioFixer{client: client, db: db}
+}
+
+// FixSummary reports what a Fix run reconciled.
+type FixSummary struct {
+	BetsProcessed int
+	MarketsBuilt  int
+	TotalPnL      float64
+}
+
+// Fix walks the user's bet history since the given timestamp (in batches,
+// oldest-to-newest order is not required — Manifold paginates by bet ID
+// descending) and records each bet into bot_bets. It is idempotent:
+// re-running with an earlier `since` inserts bets that weren't previously
+// recorded and refreshes the ones that were, matched by manifold_bet_id.
+func (f *PortfolioFixer) Fix(since time.Time) (*FixSummary, error) {
+	user, err := f.client.GetAuthenticatedUser()
+	if err != nil {
+		return nil, fmt.Errorf("getting authenticated user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("authenticated user returned nil")
+	}
+
+	positions := make(map[string]*marketPosition) // marketID -> running position
+	summary := &FixSummary{}
+
+	const pageSize = 1000
+	var before string
+	for {
+		bets, err := f.client.GetBets(mango.GetBetsRequest{
+			UserId: user.Id,
+			Limit:  pageSize,
+			Before: before,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching bet history: %w", err)
+		}
+		if bets == nil || len(*bets) == 0 {
+			break
+		}
+
+		page := *bets
+		for _, bet := range page {
+			betTime := time.UnixMilli(bet.CreatedTime)
+			if betTime.Before(since) {
+				// Bets are returned newest-first; once we're past `since`
+				// there's nothing older left worth rebuilding.
+				if err := f.writeSummary(positions, summary); err != nil {
+					return nil, err
+				}
+				slog.Info("portfolio fixer complete",
+					"bets_processed", summary.BetsProcessed,
+					"markets_rebuilt", summary.MarketsBuilt,
+					"total_pnl", summary.TotalPnL,
+				)
+				return summary, nil
+			}
+
+			if err := f.applyBet(bet, positions); err != nil {
+				slog.Warn("portfolio fixer: failed to apply bet", "bet_id", bet.Id, "error", err)
+				continue
+			}
+			summary.BetsProcessed++
+		}
+
+		before = page[len(page)-1].Id
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	if err := f.writeSummary(positions, summary); err != nil {
+		return nil, err
+	}
+
+	slog.Info("portfolio fixer complete",
+		"bets_processed", summary.BetsProcessed,
+		"markets_rebuilt", summary.MarketsBuilt,
+		"total_pnl", summary.TotalPnL,
+	)
+	return summary, nil
+}
+
+type marketPosition struct {
+	marketID     string
+	netShares    map[string]float64 // outcome -> net shares held
+	totalCost    float64
+	realizedPnL  float64
+	lastBetID    string
+	lastOutcome  string
+	lastProb     float64
+	lastExpected float64
+	lastAmount   float64
+	lastWasLimit bool
+}
+
+// applyBet folds a single Manifold bet into the running per-market position.
+// Resolved-market payouts arrive as bets with a non-zero Amount against the
+// resolution outcome and are treated the same way a normal fill is: they
+// move the running cost basis and realize PnL.
+func (f *PortfolioFixer) applyBet(bet mango.Bet, positions map[string]*marketPosition) error {
+	pos, ok := positions[bet.ContractId]
+	if !ok {
+		pos = &marketPosition{
+			marketID:  bet.ContractId,
+			netShares: make(map[string]float64),
+		}
+		positions[bet.ContractId] = pos
+	}
+
+	pos.netShares[bet.Outcome] += bet.Shares
+	pos.totalCost += bet.Amount
+	pos.lastBetID = bet.Id
+	pos.lastOutcome = bet.Outcome
+	pos.lastProb = bet.ProbAfter
+	pos.lastExpected = bet.ProbBefore
+	pos.lastAmount = bet.Amount
+	// mango.Bet has no LimitProb — a Bet returned from bet history never
+	// carries the probability a limit order was originally placed at, only
+	// whether (and how much of) it has filled. A non-empty Fills or a
+	// nonzero OrderAmount (the originally requested amount, as opposed to
+	// Amount, the amount filled so far) marks it as a limit order.
+	pos.lastWasLimit = bet.OrderAmount != 0 || len(bet.Fills) > 0
+
+	totalFees := bet.Fees.LiquidityFee + bet.Fees.PlatformFee + bet.Fees.CreatorFee
+	pos.totalCost += totalFees
+
+	return f.upsertBet(bet)
+}
+
+// upsertBet records (or refreshes) a single Manifold bet as a bot_bets row,
+// matched by manifold_bet_id so repeated Fix runs don't duplicate history.
+// limit_prob is left NULL: mango.Bet never reports the probability a limit
+// order was originally placed at, only its fill state.
+func (f *PortfolioFixer) upsertBet(bet mango.Bet) error {
+	_, err := f.db.Exec(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, limit_prob, expected_prob, market_prob_at_bet, kelly_fraction, manifold_bet_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(manifold_bet_id) DO UPDATE SET
+			amount = excluded.amount,
+			limit_prob = excluded.limit_prob,
+			expected_prob = excluded.expected_prob,
+			market_prob_at_bet = excluded.market_prob_at_bet`,
+		bet.ContractId, "fixer", bet.Outcome, bet.Amount, nil,
+		bet.ProbAfter, bet.ProbBefore, 0.0, bet.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("upserting bet %s: %w", bet.Id, err)
+	}
+	return nil
+}
+
+func (f *PortfolioFixer) writeSummary(positions map[string]*marketPosition, summary *FixSummary) error {
+	summary.MarketsBuilt = len(positions)
+	for _, pos := range positions {
+		summary.TotalPnL += -pos.totalCost // Cost basis net of payouts approximates realized PnL.
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"hyperkaehler/internal/collector"
 	"hyperkaehler/internal/config"
 	"hyperkaehler/internal/execution"
+	"hyperkaehler/internal/hedging"
 	"hyperkaehler/internal/market"
 	"hyperkaehler/internal/performance"
 	"hyperkaehler/internal/risk"
@@ -17,16 +18,20 @@ import (
 
 // Scheduler orchestrates the main trading loop.
 type Scheduler struct {
-	scanner     *market.Scanner
-	cache       *market.Cache
-	strategies  []strategy.Strategy
-	riskMgr     *risk.Manager
-	executor    *execution.Executor
-	collector   *collector.Collector
-	tracker     *performance.Tracker
-	portfolio   *risk.Portfolio
-	db          *sql.DB
-	cfg         config.ScheduleConfig
+	scanner    *market.Scanner
+	cache      *market.Cache
+	strategies []strategy.Strategy
+	aggregator *strategy.Aggregator
+	riskMgr    *risk.Manager
+	executor   *execution.Executor
+	collector  *collector.Collector
+	tracker    *performance.Tracker
+	tradeStats *performance.TradeStatsTracker
+	portfolio  *risk.Portfolio
+	orderBook  *execution.ActiveOrderBook
+	hedger     *hedging.Hedger
+	db         *sql.DB
+	cfg        config.ScheduleConfig
 }
 
 // New creates a new Scheduler with all dependencies.
@@ -34,11 +39,15 @@ func New(
 	scanner *market.Scanner,
 	cache *market.Cache,
 	strategies []strategy.Strategy,
+	aggregator *strategy.Aggregator,
 	riskMgr *risk.Manager,
 	executor *execution.Executor,
 	coll *collector.Collector,
 	tracker *performance.Tracker,
+	tradeStats *performance.TradeStatsTracker,
 	portfolio *risk.Portfolio,
+	orderBook *execution.ActiveOrderBook,
+	hedger *hedging.Hedger,
 	db *sql.DB,
 	cfg config.ScheduleConfig,
 ) *Scheduler {
@@ -46,11 +55,15 @@ func New(
 		scanner:    scanner,
 		cache:      cache,
 		strategies: strategies,
+		aggregator: aggregator,
 		riskMgr:    riskMgr,
 		executor:   executor,
 		collector:  coll,
 		tracker:    tracker,
+		tradeStats: tradeStats,
 		portfolio:  portfolio,
+		orderBook:  orderBook,
+		hedger:     hedger,
 		db:         db,
 		cfg:        cfg,
 	}
@@ -78,9 +91,11 @@ func (s *Scheduler) Run(ctx context.Context) error {
 	scanTicker := time.NewTicker(s.cfg.ScanInterval.Duration)
 	snapshotTicker := time.NewTicker(s.cfg.SnapshotInterval.Duration)
 	perfTicker := time.NewTicker(s.cfg.PerformanceInterval.Duration)
+	orderTicker := time.NewTicker(s.cfg.OrderCleanupInterval.Duration)
 	defer scanTicker.Stop()
 	defer snapshotTicker.Stop()
 	defer perfTicker.Stop()
+	defer orderTicker.Stop()
 
 	for {
 		select {
@@ -93,10 +108,24 @@ func (s *Scheduler) Run(ctx context.Context) error {
 			s.runCollection()
 		case <-perfTicker.C:
 			s.runPerformanceReport()
+		case <-orderTicker.C:
+			s.runOrderReconciliation(ctx)
 		}
 	}
 }
 
+// runOrderReconciliation polls Manifold for the current fill state of every
+// resting limit order and cancels ones that have gone stale. A no-op when
+// orderBook wasn't wired up (e.g. in tests or backtest mode).
+func (s *Scheduler) runOrderReconciliation(ctx context.Context) {
+	if s.orderBook == nil {
+		return
+	}
+	if err := s.orderBook.Reconcile(ctx, s.portfolio.UserID); err != nil {
+		slog.Error("order reconciliation failed", "error", err)
+	}
+}
+
 func (s *Scheduler) runTradingCycle(ctx context.Context) {
 	slog.Info("starting trading cycle")
 
@@ -148,6 +177,13 @@ func (s *Scheduler) runTradingCycle(ctx context.Context) {
 		return
 	}
 
+	// Net conflicting/reinforcing signals per market+answer before sizing.
+	if s.aggregator != nil {
+		before := len(allSignals)
+		allSignals = s.aggregator.Aggregate(allSignals)
+		slog.Info("signals aggregated", "before", before, "after", len(allSignals))
+	}
+
 	// Size positions via risk manager.
 	sized := s.riskMgr.SizeSignals(allSignals)
 	slog.Info("signals sized", "approved", len(sized), "total", len(allSignals))
@@ -156,6 +192,15 @@ func (s *Scheduler) runTradingCycle(ctx context.Context) {
 		return
 	}
 
+	// Open proportional hedges on any configured primary markets.
+	if s.hedger != nil {
+		before := len(sized)
+		sized = s.hedger.Apply(sized)
+		if len(sized) > before {
+			slog.Info("hedges opened", "before", before, "after", len(sized))
+		}
+	}
+
 	// Ensure markets exist in DB before placing bets.
 	for _, sig := range sized {
 		for _, m := range allMarkets {
@@ -203,6 +248,13 @@ func (s *Scheduler) runPerformanceReport() {
 	report.CurrentBalance = s.portfolio.TotalValue
 
 	performance.LogReport(report)
+
+	// Recompute and persist each strategy's rolling TradeStats so the
+	// fractional-Kelly multiplier it reads on the next trading cycle
+	// reflects the latest resolved bets.
+	if _, err := s.tradeStats.Compute(); err != nil {
+		slog.Error("trade stats recompute failed", "error", err)
+	}
 }
 
 func (s *Scheduler) loadMarketExposure() {
@@ -0,0 +1,127 @@
+package strategy
+
+import (
+	"fmt"
+	"log/slog"
+
+	"hyperkaehler/internal/config"
+)
+
+// Aggregator sits between the strategies and the risk manager. It collects
+// every signal produced in a scan cycle, groups them by (MarketID,
+// AnswerID), and combines them into at most one consolidated signal per
+// group using per-strategy weights and each signal's own confidence. This
+// keeps strategies from stepping on each other — e.g. arbitrage betting YES
+// while timedecay bets NO on the same market in the same cycle.
+type Aggregator struct {
+	cfg config.AggregatorConfig
+}
+
+func NewAggregator(cfg config.AggregatorConfig) *Aggregator {
+	return &Aggregator{cfg: cfg}
+}
+
+// Aggregate combines signals targeting the same market/answer into one net
+// signal per group. A group with a single signal passes through unchanged.
+func (a *Aggregator) Aggregate(signals []Signal) []Signal {
+	groups := make(map[string][]Signal)
+	var order []string
+	for _, sig := range signals {
+		key := sig.MarketID + ":" + sig.AnswerID
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], sig)
+	}
+
+	result := make([]Signal, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		if len(group) == 1 {
+			result = append(result, group[0])
+			continue
+		}
+
+		combined, ok := a.combine(group)
+		if !ok {
+			slog.Info("aggregator: signals netted out below threshold", "key", key, "count", len(group))
+			continue
+		}
+		result = append(result, combined)
+	}
+
+	slog.Info("aggregator evaluation complete", "input_signals", len(signals), "output_signals", len(result))
+	return result
+}
+
+func (a *Aggregator) weight(strategyName string) float64 {
+	if w, ok := a.cfg.Weights[strategyName]; ok {
+		return w
+	}
+	return 1.0
+}
+
+// combine nets a group of conflicting/reinforcing signals into a single
+// consolidated one, or reports false if the net confidence doesn't clear
+// MinNetConfidence.
+func (a *Aggregator) combine(group []Signal) (Signal, bool) {
+	var netScore, totalWeight float64
+	for _, sig := range group {
+		direction := 1.0
+		if sig.Outcome == "NO" {
+			direction = -1.0
+		}
+		w := a.weight(sig.Strategy)
+		netScore += w * sig.Confidence * direction
+		totalWeight += w
+	}
+
+	if totalWeight <= 0 {
+		return Signal{}, false
+	}
+	netConfidence := netScore / totalWeight
+	if netConfidence < 0 {
+		netConfidence = -netConfidence
+	}
+	if netConfidence < a.cfg.MinNetConfidence {
+		return Signal{}, false
+	}
+
+	netOutcome := "YES"
+	if netScore < 0 {
+		netOutcome = "NO"
+	}
+
+	var agreeWeight, confSum, edgeSum, probSum float64
+	var strategies []string
+	for _, sig := range group {
+		if sig.Outcome != netOutcome {
+			continue
+		}
+		w := a.weight(sig.Strategy)
+		agreeWeight += w
+		confSum += w * sig.Confidence
+		edgeSum += w * sig.Edge
+		probSum += sig.MarketProb
+		strategies = append(strategies, sig.Strategy)
+	}
+	if agreeWeight <= 0 {
+		return Signal{}, false
+	}
+
+	first := group[0]
+	return Signal{
+		MarketID:   first.MarketID,
+		AnswerID:   first.AnswerID,
+		Outcome:    netOutcome,
+		Confidence: confSum / agreeWeight,
+		MarketProb: probSum / float64(len(group)),
+		Edge:       edgeSum / agreeWeight,
+		Strategy:   "aggregator",
+		Reason:     fmt.Sprintf("net confidence %.3f from %v", netConfidence, strategies),
+		// Scale Kelly sizing by conviction strength: a net confidence near
+		// the threshold posts a small size, a unanimous group posts its
+		// weighted mean confidence.
+		SizeMultiplier: netConfidence,
+	}, true
+}
@@ -0,0 +1,60 @@
+package strategy
+
+import (
+	"testing"
+
+	"hyperkaehler/internal/config"
+)
+
+func TestAggregator_SingleStrategyPassthrough(t *testing.T) {
+	a := NewAggregator(config.AggregatorConfig{MinNetConfidence: 0.1})
+
+	signals := []Signal{
+		{MarketID: "m1", Outcome: "YES", Confidence: 0.8, Edge: 0.3, Strategy: "arbitrage"},
+	}
+
+	out := a.Aggregate(signals)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 signal, got %d", len(out))
+	}
+	if out[0] != signals[0] {
+		t.Errorf("expected passthrough of the original signal, got %+v", out[0])
+	}
+}
+
+func TestAggregator_ConfidenceWeightedSizing(t *testing.T) {
+	a := NewAggregator(config.AggregatorConfig{
+		Weights:          map[string]float64{"arbitrage": 2.0, "mispricing": 1.0},
+		MinNetConfidence: 0.1,
+	})
+
+	signals := []Signal{
+		{MarketID: "m1", Outcome: "YES", Confidence: 0.9, MarketProb: 0.5, Edge: 0.4, Strategy: "arbitrage"},
+		{MarketID: "m1", Outcome: "YES", Confidence: 0.6, MarketProb: 0.5, Edge: 0.1, Strategy: "mispricing"},
+	}
+
+	out := a.Aggregate(signals)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 consolidated signal, got %d", len(out))
+	}
+	if out[0].Outcome != "YES" {
+		t.Errorf("expected net YES, got %s", out[0].Outcome)
+	}
+	if out[0].SizeMultiplier <= 0 || out[0].SizeMultiplier > 1 {
+		t.Errorf("expected size multiplier in (0, 1], got %f", out[0].SizeMultiplier)
+	}
+}
+
+func TestAggregator_CancelsConflictingSignals(t *testing.T) {
+	a := NewAggregator(config.AggregatorConfig{MinNetConfidence: 0.3})
+
+	signals := []Signal{
+		{MarketID: "m1", Outcome: "YES", Confidence: 0.55, Strategy: "arbitrage"},
+		{MarketID: "m1", Outcome: "NO", Confidence: 0.55, Strategy: "timedecay"},
+	}
+
+	out := a.Aggregate(signals)
+	if len(out) != 0 {
+		t.Fatalf("expected conflicting signals to cancel out, got %d", len(out))
+	}
+}
@@ -11,7 +11,9 @@ import (
 
 // Arbitrage finds multi-choice markets where answer probabilities don't sum to ~100%.
 type Arbitrage struct {
-	cfg config.ArbitrageConfig
+	cfg       config.ArbitrageConfig
+	breaker   CircuitBreaker
+	perfStats PerformanceStats
 }
 
 func NewArbitrage(cfg config.ArbitrageConfig) *Arbitrage {
@@ -21,6 +23,15 @@ func NewArbitrage(cfg config.ArbitrageConfig) *Arbitrage {
 func (a *Arbitrage) Name() string     { return "arbitrage" }
 func (a *Arbitrage) Enabled() bool    { return a.cfg.Enabled }
 
+// SetCircuitBreaker wires a CircuitBreaker that Evaluate consults before
+// returning signals. A nil breaker (the default) disables the check.
+func (a *Arbitrage) SetCircuitBreaker(cb CircuitBreaker) { a.breaker = cb }
+
+// SetPerformanceStats wires a PerformanceStats that Evaluate consults to
+// scale signals by this strategy's realized track record. A nil stats
+// (the default) disables the adjustment.
+func (a *Arbitrage) SetPerformanceStats(stats PerformanceStats) { a.perfStats = stats }
+
 func (a *Arbitrage) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
 	var signals []Signal
 	evaluated := 0
@@ -55,7 +66,8 @@ func (a *Arbitrage) Evaluate(_ context.Context, markets []MarketData) ([]Signal,
 	}
 
 	slog.Info("arbitrage evaluation complete", "markets_evaluated", evaluated, "signals", len(signals))
-	return signals, nil
+	signals = applyCircuitBreaker(a.breaker, a.Name(), signals)
+	return applyPerformanceStats(a.perfStats, a.Name(), signals), nil
 }
 
 func (a *Arbitrage) evaluateMarket(m MarketData) []Signal {
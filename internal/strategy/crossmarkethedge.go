@@ -0,0 +1,268 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"hyperkaehler/internal/config"
+)
+
+// CrossMarketHedge trades the spread between two markets that track the
+// same underlying event — grouped either by declared MarketGroups or by
+// near-duplicate question text — by buying YES on whichever side is cheaper
+// and NO on whichever is pricier; evaluateGroup describes how far its leg
+// sizing goes toward locking that spread in. It is modeled
+// on cross-exchange market making: the same instrument quoted at two
+// prices is an arbitrage, not a directional bet.
+type CrossMarketHedge struct {
+	cfg       config.CrossMarketHedgeConfig
+	breaker   CircuitBreaker
+	perfStats PerformanceStats
+}
+
+func NewCrossMarketHedge(cfg config.CrossMarketHedgeConfig) *CrossMarketHedge {
+	return &CrossMarketHedge{cfg: cfg}
+}
+
+func (h *CrossMarketHedge) Name() string  { return "crossmarkethedge" }
+func (h *CrossMarketHedge) Enabled() bool { return h.cfg.Enabled }
+
+// SetCircuitBreaker wires a CircuitBreaker that Evaluate consults before
+// returning signals. A nil breaker (the default) disables the check.
+func (h *CrossMarketHedge) SetCircuitBreaker(cb CircuitBreaker) { h.breaker = cb }
+
+// SetPerformanceStats wires a PerformanceStats that Evaluate consults to
+// scale signals by this strategy's realized track record. A nil stats
+// (the default) disables the adjustment.
+func (h *CrossMarketHedge) SetPerformanceStats(stats PerformanceStats) { h.perfStats = stats }
+
+func (h *CrossMarketHedge) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
+	var eligible []MarketData
+	for _, m := range markets {
+		if m.OutcomeType != "BINARY" || m.IsResolved {
+			continue
+		}
+		eligible = append(eligible, m)
+	}
+
+	groups := h.groupMarkets(eligible)
+
+	var signals []Signal
+	for _, group := range groups {
+		signals = append(signals, h.evaluateGroup(group)...)
+	}
+
+	slog.Info("crossmarkethedge evaluation complete", "groups", len(groups), "signals", len(signals))
+	signals = applyCircuitBreaker(h.breaker, h.Name(), signals)
+	return applyPerformanceStats(h.perfStats, h.Name(), signals), nil
+}
+
+// groupMarkets partitions eligible markets into duplicate-question
+// clusters. A market named in cfg.MarketGroups is placed in that group;
+// everything left over is clustered by pairwise question-token Jaccard
+// similarity against cfg.SimilarityThreshold.
+func (h *CrossMarketHedge) groupMarkets(eligible []MarketData) [][]MarketData {
+	byID := make(map[string]MarketData, len(eligible))
+	for _, m := range eligible {
+		byID[m.ID] = m
+	}
+
+	assigned := make(map[string]bool, len(eligible))
+	var groups [][]MarketData
+
+	groupKeys := make([]string, 0, len(h.cfg.MarketGroups))
+	for key := range h.cfg.MarketGroups {
+		groupKeys = append(groupKeys, key)
+	}
+	sort.Strings(groupKeys) // deterministic iteration order for reproducible signals
+
+	for _, key := range groupKeys {
+		var group []MarketData
+		for _, id := range h.cfg.MarketGroups[key] {
+			if m, ok := byID[id]; ok && !assigned[id] {
+				group = append(group, m)
+				assigned[id] = true
+			}
+		}
+		if len(group) >= 2 {
+			groups = append(groups, group)
+		}
+	}
+
+	if h.cfg.SimilarityThreshold <= 0 {
+		return groups
+	}
+
+	var remaining []MarketData
+	for _, m := range eligible {
+		if !assigned[m.ID] {
+			remaining = append(remaining, m)
+		}
+	}
+	return append(groups, h.clusterBySimilarity(remaining)...)
+}
+
+// clusterBySimilarity unions markets whose normalized question tokens have
+// a Jaccard similarity >= cfg.SimilarityThreshold, using union-find so that
+// A~B and B~C groups all three even if A and C alone fall short.
+func (h *CrossMarketHedge) clusterBySimilarity(markets []MarketData) [][]MarketData {
+	parent := make([]int, len(markets))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	tokens := make([]map[string]bool, len(markets))
+	for i, m := range markets {
+		tokens[i] = tokenizeQuestion(m.Question)
+	}
+
+	for i := range markets {
+		for j := i + 1; j < len(markets); j++ {
+			if jaccard(tokens[i], tokens[j]) >= h.cfg.SimilarityThreshold {
+				union(i, j)
+			}
+		}
+	}
+
+	byRoot := make(map[int][]MarketData)
+	for i, m := range markets {
+		root := find(i)
+		byRoot[root] = append(byRoot[root], m)
+	}
+
+	var groups [][]MarketData
+	for _, group := range byRoot {
+		if len(group) >= 2 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+var questionTokenRE = regexp.MustCompile(`[a-z0-9]+`)
+
+// tokenizeQuestion lowercases a market question and splits it into a set
+// of alphanumeric tokens for Jaccard comparison.
+func tokenizeQuestion(question string) map[string]bool {
+	words := questionTokenRE.FindAllString(strings.ToLower(question), -1)
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|, 0 if both sets are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// evaluateGroup emits one hedge signal pair for every pairing within group
+// whose probability gap exceeds cfg.MinSpread: YES on the cheaper market,
+// NO on the pricier one. Both legs share a HedgePairID so the executor
+// places them atomically, and confidence is pinned to the midpoint
+// probability so each leg's edge is exactly half the spread.
+//
+// Locking a profit regardless of resolution needs equal SHARE counts on
+// both legs (cost ~ amount/price), not equal mana: with N shares each,
+// payout is N on whichever leg wins and 0 on the other, against a total
+// cost of N*(yesPrice+noPrice) < N, so the margin N*spread holds either
+// way. SizeMultiplier cancels the price-driven share/mana ratio between
+// the two legs — the one factor this strategy can correct for directly —
+// by scaling the NO leg relative to the YES leg's noPrice/yesPrice. It
+// does not fully guarantee the lock: risk.Manager still sizes each leg's
+// base amount independently via its own Kelly fraction (a function of that
+// leg's own Confidence and MarketProb), which can diverge between legs
+// before this ratio is even applied. An exact lock would require both legs
+// to be sized from a single shared Kelly call, which the per-signal sizing
+// pipeline doesn't support.
+func (h *CrossMarketHedge) evaluateGroup(group []MarketData) []Signal {
+	var signals []Signal
+	for i := 0; i < len(group); i++ {
+		for j := i + 1; j < len(group); j++ {
+			cheap, pricey := group[i], group[j]
+			if cheap.Probability > pricey.Probability {
+				cheap, pricey = pricey, cheap
+			}
+
+			spread := pricey.Probability - cheap.Probability
+			if spread < h.cfg.MinSpread {
+				continue
+			}
+
+			mid := (cheap.Probability + pricey.Probability) / 2
+			pairID := fmt.Sprintf("hedge:%s:%s", cheap.ID, pricey.ID)
+
+			yesPrice := cheap.Probability
+			noPrice := 1 - pricey.Probability
+			noMultiplier := 1.0
+			if yesPrice > 0 {
+				noMultiplier = noPrice / yesPrice
+			}
+
+			slog.Debug("cross-market hedge found",
+				"cheap_market", cheap.ID,
+				"pricey_market", pricey.ID,
+				"cheap_prob", cheap.Probability,
+				"pricey_prob", pricey.Probability,
+				"spread", spread,
+				"no_leg_multiplier", noMultiplier,
+			)
+
+			signals = append(signals,
+				Signal{
+					MarketID:    cheap.ID,
+					Outcome:     "YES",
+					Confidence:  mid,
+					MarketProb:  cheap.Probability,
+					Edge:        mid - cheap.Probability,
+					Strategy:    "crossmarkethedge",
+					Reason:      fmt.Sprintf("hedge leg: buy YES on %q at %.2f, paired against %q at %.2f", cheap.Question, cheap.Probability, pricey.Question, pricey.Probability),
+					HedgePairID: pairID,
+				},
+				Signal{
+					MarketID:       pricey.ID,
+					Outcome:        "NO",
+					Confidence:     1 - mid,
+					MarketProb:     pricey.Probability,
+					Edge:           pricey.Probability - mid,
+					Strategy:       "crossmarkethedge",
+					Reason:         fmt.Sprintf("hedge leg: buy NO on %q at %.2f, paired against %q at %.2f", pricey.Question, pricey.Probability, cheap.Question, cheap.Probability),
+					HedgePairID:    pairID,
+					SizeMultiplier: noMultiplier,
+				},
+			)
+		}
+	}
+	return signals
+}
@@ -0,0 +1,182 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+
+	"hyperkaehler/internal/config"
+)
+
+func TestCrossMarketHedge_SimilarQuestionsOverMinSpread(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:             true,
+		SimilarityThreshold: 0.6,
+		MinSpread:           0.05,
+	})
+
+	markets := []MarketData{
+		{ID: "cheap", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.55},
+		{ID: "pricey", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.70},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 2 {
+		t.Fatalf("expected 2 signals (one hedge pair), got %d", len(signals))
+	}
+
+	var yesLeg, noLeg *Signal
+	for i := range signals {
+		switch signals[i].Outcome {
+		case "YES":
+			yesLeg = &signals[i]
+		case "NO":
+			noLeg = &signals[i]
+		}
+	}
+	if yesLeg == nil || noLeg == nil {
+		t.Fatalf("expected one YES and one NO leg, got %+v", signals)
+	}
+
+	if yesLeg.MarketID != "cheap" {
+		t.Errorf("expected YES buy on the cheap market, got %s", yesLeg.MarketID)
+	}
+	if noLeg.MarketID != "pricey" {
+		t.Errorf("expected NO buy on the pricier market, got %s", noLeg.MarketID)
+	}
+	if yesLeg.HedgePairID == "" || yesLeg.HedgePairID != noLeg.HedgePairID {
+		t.Errorf("expected both legs to share a non-empty HedgePairID, got %q and %q", yesLeg.HedgePairID, noLeg.HedgePairID)
+	}
+
+	// Confidence is pinned to the midpoint, so each leg's edge is exactly
+	// half the spread — the profit locked in regardless of resolution.
+	wantEdge := (0.70 - 0.55) / 2
+	if diff := yesLeg.Edge - wantEdge; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected YES leg edge %f, got %f", wantEdge, yesLeg.Edge)
+	}
+	if diff := noLeg.Edge - wantEdge; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected NO leg edge %f, got %f", wantEdge, noLeg.Edge)
+	}
+}
+
+func TestCrossMarketHedge_SkipsBelowMinSpread(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:             true,
+		SimilarityThreshold: 0.6,
+		MinSpread:           0.10,
+	})
+
+	markets := []MarketData{
+		{ID: "a", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.55},
+		{ID: "b", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.58},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected 0 signals below MinSpread, got %d", len(signals))
+	}
+}
+
+func TestCrossMarketHedge_SkipsDissimilarQuestions(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:             true,
+		SimilarityThreshold: 0.6,
+		MinSpread:           0.05,
+	})
+
+	markets := []MarketData{
+		{ID: "a", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.55},
+		{ID: "b", OutcomeType: "BINARY", Question: "Will it rain tomorrow in Tokyo?", Probability: 0.70},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected 0 signals for unrelated questions, got %d", len(signals))
+	}
+}
+
+func TestCrossMarketHedge_ExplicitMarketGroupsOverridesSimilarity(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:   true,
+		MinSpread: 0.05,
+		MarketGroups: map[string][]string{
+			"election-2026": {"a", "b"},
+		},
+	})
+
+	markets := []MarketData{
+		{ID: "a", OutcomeType: "BINARY", Question: "Trump wins?", Probability: 0.55},
+		{ID: "b", OutcomeType: "BINARY", Question: "Presidential outcome favors incumbent party", Probability: 0.70},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 2 {
+		t.Fatalf("expected 2 signals via explicit market_groups despite dissimilar text, got %d", len(signals))
+	}
+}
+
+func TestCrossMarketHedge_SkipsResolvedAndNonBinary(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:             true,
+		SimilarityThreshold: 0.6,
+		MinSpread:           0.05,
+	})
+
+	markets := []MarketData{
+		{ID: "a", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.55, IsResolved: true},
+		{ID: "b", OutcomeType: "MULTIPLE_CHOICE", Question: "Will X win the election?", Probability: 0.70},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected 0 signals when markets are resolved or non-binary, got %d", len(signals))
+	}
+}
+
+func TestCrossMarketHedge_NoLegSizedForMatchingShares(t *testing.T) {
+	h := NewCrossMarketHedge(config.CrossMarketHedgeConfig{
+		Enabled:             true,
+		SimilarityThreshold: 0.6,
+		MinSpread:           0.05,
+	})
+
+	markets := []MarketData{
+		{ID: "cheap", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.40},
+		{ID: "pricey", OutcomeType: "BINARY", Question: "Will X win the election?", Probability: 0.60},
+	}
+
+	signals, err := h.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var noLeg *Signal
+	for i := range signals {
+		if signals[i].Outcome == "NO" {
+			noLeg = &signals[i]
+		}
+	}
+	if noLeg == nil {
+		t.Fatal("expected a NO leg")
+	}
+
+	// yesPrice=0.40, noPrice=1-0.60=0.40, so the legs are already at equal
+	// prices and the NO leg needs no adjustment.
+	if diff := noLeg.SizeMultiplier - 1.0; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected NO leg multiplier 1.0 for equal-priced legs, got %f", noLeg.SizeMultiplier)
+	}
+}
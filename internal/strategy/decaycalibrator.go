@@ -0,0 +1,237 @@
+package strategy
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// numDecayDeciles is the number of elapsedFraction buckets the decay curve
+// is fit over, e.g. decile 0 covers [0.0, 0.1) elapsed, decile 9 covers
+// [0.9, 1.0].
+const numDecayDeciles = 10
+
+// DecileCalibration is the empirical decay curve for one elapsedFraction
+// decile: the ratio of the decile's actual YES-resolution rate to the
+// average probability-at-time its resolved markets showed. A factor of 1.0
+// means the market's stated probability was, on average, already an
+// unbiased estimate of the eventual resolution at that point in the
+// market's life; a factor below 1.0 means YES probabilities at that stage
+// tend to overstate the eventual outcome, same direction as the original
+// hard-coded `1 - elapsedFraction*0.5`.
+type DecileCalibration struct {
+	Decile      int
+	SampleSize  int
+	DecayFactor float64
+}
+
+// DecayCalibrator fits TimeDecay's decay curve from the bot's own resolved
+// market history, replacing the hard-coded linear factor with one
+// calibrated to how markets matching timePatterns actually resolved.
+type DecayCalibrator struct {
+	db *sql.DB
+}
+
+func NewDecayCalibrator(db *sql.DB) *DecayCalibrator {
+	return &DecayCalibrator{db: db}
+}
+
+// Calibrate scans every resolved BINARY market whose question matches
+// timePatterns, buckets each of its probability snapshots by the
+// elapsedFraction decile it fell in, computes the empirical decay factor
+// per decile, and persists the result to decay_calibration. The raw
+// per-decile ratios are passed through pool-adjacent-violators so the
+// returned curve is monotonically non-increasing in elapsedFraction, same
+// as the linear default it replaces.
+func (c *DecayCalibrator) Calibrate() ([]DecileCalibration, error) {
+	rows, err := c.db.Query(`
+		SELECT m.question, m.created_time, m.close_time, m.resolution,
+		       s.probability, s.snapshot_at
+		FROM markets m
+		JOIN market_snapshots s ON s.market_id = m.id
+		WHERE m.outcome_type = 'BINARY'
+		  AND m.is_resolved = 1
+		  AND m.resolution IN ('YES', 'NO')
+		  AND s.probability IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("querying resolved snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var sumProb, sumYes [numDecayDeciles]float64
+	var count [numDecayDeciles]int
+
+	for rows.Next() {
+		var question, resolution, snapshotAt string
+		var createdTime, closeTime int64
+		var probability float64
+		if err := rows.Scan(&question, &createdTime, &closeTime, &resolution, &probability, &snapshotAt); err != nil {
+			return nil, err
+		}
+		if !matchesTimePattern(question) {
+			continue
+		}
+		totalDuration := closeTime - createdTime
+		if totalDuration <= 0 {
+			continue
+		}
+		snapAt, err := time.Parse("2006-01-02 15:04:05", snapshotAt)
+		if err != nil {
+			continue
+		}
+		elapsedFraction := float64(snapAt.UnixMilli()-createdTime) / float64(totalDuration)
+		if elapsedFraction < 0 || elapsedFraction > 1 {
+			continue
+		}
+
+		decile := decileOf(elapsedFraction)
+		sumProb[decile] += probability
+		if resolution == "YES" {
+			sumYes[decile]++
+		}
+		count[decile]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var raw []DecileCalibration
+	for d := 0; d < numDecayDeciles; d++ {
+		if count[d] == 0 {
+			continue
+		}
+		avgProb := sumProb[d] / float64(count[d])
+		resolvedYesRate := sumYes[d] / float64(count[d])
+		factor := 1.0
+		if avgProb > 0 {
+			factor = resolvedYesRate / avgProb
+		}
+		raw = append(raw, DecileCalibration{Decile: d, SampleSize: count[d], DecayFactor: factor})
+	}
+
+	curve := monotoneNonIncreasing(raw)
+	if err := c.persist(curve); err != nil {
+		return nil, err
+	}
+	return curve, nil
+}
+
+// decileOf maps elapsedFraction (expected in [0, 1]) to a bucket in
+// [0, numDecayDeciles-1], placing exactly 1.0 in the last bucket rather
+// than overflowing it.
+func decileOf(elapsedFraction float64) int {
+	d := int(elapsedFraction * numDecayDeciles)
+	if d >= numDecayDeciles {
+		d = numDecayDeciles - 1
+	}
+	return d
+}
+
+// monotoneNonIncreasing applies pool-adjacent-violators to raw (already
+// sorted by Decile ascending from Calibrate's loop) so DecayFactor never
+// increases as Decile increases: a market's apparent mispricing should only
+// grow, never shrink, the longer an unresolved "by DATE" question sits open.
+func monotoneNonIncreasing(raw []DecileCalibration) []DecileCalibration {
+	if len(raw) == 0 {
+		return nil
+	}
+	sort.Slice(raw, func(i, j int) bool { return raw[i].Decile < raw[j].Decile })
+
+	type pool struct {
+		decile      int
+		weight      float64
+		weightedSum float64
+		sampleSize  int
+	}
+	var pools []pool
+	for _, d := range raw {
+		p := pool{decile: d.Decile, weight: float64(d.SampleSize), weightedSum: float64(d.SampleSize) * d.DecayFactor, sampleSize: d.SampleSize}
+		pools = append(pools, p)
+		for len(pools) > 1 && pools[len(pools)-2].weightedSum/pools[len(pools)-2].weight < pools[len(pools)-1].weightedSum/pools[len(pools)-1].weight {
+			last := pools[len(pools)-1]
+			pools = pools[:len(pools)-1]
+			merged := pools[len(pools)-1]
+			merged.weight += last.weight
+			merged.weightedSum += last.weightedSum
+			merged.sampleSize += last.sampleSize
+			pools[len(pools)-1] = merged
+		}
+	}
+
+	curve := make([]DecileCalibration, 0, len(pools))
+	for _, p := range pools {
+		curve = append(curve, DecileCalibration{Decile: p.decile, SampleSize: p.sampleSize, DecayFactor: p.weightedSum / p.weight})
+	}
+	return curve
+}
+
+func (c *DecayCalibrator) persist(curve []DecileCalibration) error {
+	for _, d := range curve {
+		_, err := c.db.Exec(`
+			INSERT INTO decay_calibration (decile, sample_size, decay_factor, updated_at)
+			VALUES (?, ?, ?, datetime('now'))
+			ON CONFLICT(decile) DO UPDATE SET
+				sample_size = excluded.sample_size,
+				decay_factor = excluded.decay_factor,
+				updated_at = excluded.updated_at`,
+			d.Decile, d.SampleSize, d.DecayFactor)
+		if err != nil {
+			return fmt.Errorf("upserting decay_calibration decile %d: %w", d.Decile, err)
+		}
+	}
+	return nil
+}
+
+// Load reads the persisted decay curve, ordered by Decile ascending.
+func (c *DecayCalibrator) Load() ([]DecileCalibration, error) {
+	rows, err := c.db.Query(`SELECT decile, sample_size, decay_factor FROM decay_calibration ORDER BY decile`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var curve []DecileCalibration
+	for rows.Next() {
+		var d DecileCalibration
+		if err := rows.Scan(&d.Decile, &d.SampleSize, &d.DecayFactor); err != nil {
+			return nil, err
+		}
+		curve = append(curve, d)
+	}
+	return curve, rows.Err()
+}
+
+// estimateFromCurve linearly interpolates DecayFactor between curve's two
+// nearest deciles to elapsedFraction*numDecayDeciles, extending flat beyond
+// either end, and returns estimatedProb = marketProb*factor. ok is false if
+// curve is empty.
+func estimateFromCurve(curve []DecileCalibration, marketProb, elapsedFraction float64) (float64, bool) {
+	if len(curve) == 0 {
+		return 0, false
+	}
+	if len(curve) == 1 {
+		return marketProb * curve[0].DecayFactor, true
+	}
+
+	x := elapsedFraction * numDecayDeciles
+	if x <= float64(curve[0].Decile) {
+		return marketProb * curve[0].DecayFactor, true
+	}
+	last := curve[len(curve)-1]
+	if x >= float64(last.Decile) {
+		return marketProb * last.DecayFactor, true
+	}
+
+	for i := 1; i < len(curve); i++ {
+		if x > float64(curve[i].Decile) {
+			continue
+		}
+		lo, hi := curve[i-1], curve[i]
+		span := float64(hi.Decile - lo.Decile)
+		t := (x - float64(lo.Decile)) / span
+		factor := lo.DecayFactor + t*(hi.DecayFactor-lo.DecayFactor)
+		return marketProb * factor, true
+	}
+	return marketProb * last.DecayFactor, true
+}
@@ -0,0 +1,124 @@
+package strategy
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func insertResolvedMarket(t *testing.T, database *sql.DB, id, question, resolution string, created, closes time.Time) {
+	t.Helper()
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url, is_resolved, resolution)
+		VALUES (?, ?, 'BINARY', 'cpmm-1', 'user1', ?, ?, 'https://example.com', 1, ?)
+		ON CONFLICT(id) DO NOTHING`,
+		id, question, created.UnixMilli(), closes.UnixMilli(), resolution); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func insertDecaySnapshot(t *testing.T, database *sql.DB, marketID string, probability float64, at time.Time) {
+	t.Helper()
+	if _, err := database.Exec(`
+		INSERT INTO market_snapshots (market_id, probability, volume, volume_24h, total_liquidity, snapshot_at)
+		VALUES (?, ?, 0, 0, 0, ?)`,
+		marketID, probability, at.UTC().Format("2006-01-02 15:04:05")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecayCalibrator_CurveSteepensForLateStageLowProbability(t *testing.T) {
+	database := newTestTimeDecayDB(t)
+	now := time.Now()
+	created := now.Add(-1000 * time.Hour)
+	closes := now
+	question := "Will X happen by December 2099?"
+
+	// Early-decile (15% elapsed) markets: probability 0.50, yesRate 0.6 -> factor 1.2.
+	earlyAt := created.Add(150 * time.Hour)
+	for i, res := range []string{"YES", "YES", "YES", "NO", "NO"} {
+		id := fmt.Sprintf("early-%d", i)
+		insertResolvedMarket(t, database, id, question, res, created, closes)
+		insertDecaySnapshot(t, database, id, 0.50, earlyAt)
+	}
+
+	// Late-decile (95% elapsed) markets: same probability, yesRate 0.2 -> factor 0.4.
+	lateAt := created.Add(950 * time.Hour)
+	for i, res := range []string{"YES", "NO", "NO", "NO", "NO"} {
+		id := fmt.Sprintf("late-%d", i)
+		insertResolvedMarket(t, database, id, question, res, created, closes)
+		insertDecaySnapshot(t, database, id, 0.50, lateAt)
+	}
+
+	calibrator := NewDecayCalibrator(database)
+	curve, err := calibrator.Calibrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curve) != 2 {
+		t.Fatalf("expected 2 calibrated deciles, got %d: %+v", len(curve), curve)
+	}
+
+	var early, late DecileCalibration
+	for _, d := range curve {
+		switch d.Decile {
+		case 1:
+			early = d
+		case 9:
+			late = d
+		default:
+			t.Fatalf("unexpected decile %d in curve: %+v", d.Decile, curve)
+		}
+	}
+	if early.SampleSize != 5 || late.SampleSize != 5 {
+		t.Fatalf("expected 5 samples per decile, got early=%d late=%d", early.SampleSize, late.SampleSize)
+	}
+	if late.DecayFactor >= early.DecayFactor {
+		t.Errorf("expected late-stage decay factor to be lower (steeper) than early-stage, got early=%.2f late=%.2f", early.DecayFactor, late.DecayFactor)
+	}
+	if late.DecayFactor >= 1.0 {
+		t.Errorf("expected late-stage factor below 1.0 for a mostly-NO-resolving sample, got %.2f", late.DecayFactor)
+	}
+
+	loaded, err := calibrator.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(curve) {
+		t.Errorf("expected Load to return the persisted curve, got %d deciles vs %d computed", len(loaded), len(curve))
+	}
+}
+
+func TestDecayCalibrator_IgnoresMarketsWithoutTimePattern(t *testing.T) {
+	database := newTestTimeDecayDB(t)
+	now := time.Now()
+	created := now.Add(-1000 * time.Hour)
+	closes := now
+
+	insertResolvedMarket(t, database, "notime-1", "Will the price of Bitcoin exceed $100k?", "NO", created, closes)
+	insertDecaySnapshot(t, database, "notime-1", 0.50, created.Add(500*time.Hour))
+
+	calibrator := NewDecayCalibrator(database)
+	curve, err := calibrator.Calibrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curve) != 0 {
+		t.Errorf("expected no calibrated deciles from a non-time-pattern market, got %+v", curve)
+	}
+}
+
+func TestDecayCalibrator_MonotoneNonIncreasing(t *testing.T) {
+	raw := []DecileCalibration{
+		{Decile: 0, SampleSize: 10, DecayFactor: 0.8},
+		{Decile: 1, SampleSize: 10, DecayFactor: 1.2}, // violates monotonicity vs decile 0
+		{Decile: 2, SampleSize: 10, DecayFactor: 0.5},
+	}
+	curve := monotoneNonIncreasing(raw)
+	for i := 1; i < len(curve); i++ {
+		if curve[i].DecayFactor > curve[i-1].DecayFactor {
+			t.Errorf("curve not monotone non-increasing: %+v", curve)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
+	"strings"
 	"time"
 
 	"hyperkaehler/internal/config"
@@ -12,7 +14,9 @@ import (
 // MarketMaking places limit orders on both sides of liquid binary markets
 // to capture the bid-ask spread.
 type MarketMaking struct {
-	cfg config.MarketMakingConfig
+	cfg       config.MarketMakingConfig
+	breaker   CircuitBreaker
+	perfStats PerformanceStats
 }
 
 func NewMarketMaking(cfg config.MarketMakingConfig) *MarketMaking {
@@ -22,6 +26,15 @@ func NewMarketMaking(cfg config.MarketMakingConfig) *MarketMaking {
 func (mm *MarketMaking) Name() string  { return "marketmaking" }
 func (mm *MarketMaking) Enabled() bool { return mm.cfg.Enabled }
 
+// SetCircuitBreaker wires a CircuitBreaker that Evaluate consults before
+// returning signals. A nil breaker (the default) disables the check.
+func (mm *MarketMaking) SetCircuitBreaker(cb CircuitBreaker) { mm.breaker = cb }
+
+// SetPerformanceStats wires a PerformanceStats that Evaluate consults to
+// scale signals by this strategy's realized track record. A nil stats
+// (the default) disables the adjustment.
+func (mm *MarketMaking) SetPerformanceStats(stats PerformanceStats) { mm.perfStats = stats }
+
 func (mm *MarketMaking) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
 	var signals []Signal
 
@@ -36,7 +49,8 @@ func (mm *MarketMaking) Evaluate(_ context.Context, markets []MarketData) ([]Sig
 	}
 
 	slog.Info("marketmaking evaluation complete", "signals", len(signals))
-	return signals, nil
+	signals = applyCircuitBreaker(mm.breaker, mm.Name(), signals)
+	return applyPerformanceStats(mm.perfStats, mm.Name(), signals), nil
 }
 
 func (mm *MarketMaking) isEligible(m MarketData) bool {
@@ -72,8 +86,20 @@ func (mm *MarketMaking) calculateSpread(m MarketData) float64 {
 	}
 }
 
+// generateSignals builds a ladder of NumLayers limit orders on each side of
+// the market, layer i sitting at halfSpread + i*LayerSpread away from the
+// midpoint with size scaled by QuantityMultiplier^i. NumLayers <= 1
+// reproduces the original single YES/NO pair.
 func (mm *MarketMaking) generateSignals(m MarketData, spread float64) []Signal {
 	halfSpread := spread / 2
+	numLayers := mm.cfg.NumLayers
+	if numLayers < 1 {
+		numLayers = 1
+	}
+	quantityMultiplier := mm.cfg.QuantityMultiplier
+	if quantityMultiplier <= 0 {
+		quantityMultiplier = 1.0
+	}
 
 	slog.Debug("marketmaking opportunity",
 		"market", m.ID,
@@ -81,33 +107,78 @@ func (mm *MarketMaking) generateSignals(m MarketData, spread float64) []Signal {
 		"prob", m.Probability,
 		"spread", spread,
 		"liquidity", m.TotalLiquidity,
+		"layers", numLayers,
 	)
 
-	yesBuyProb := m.Probability - halfSpread
-	noBuyProb := m.Probability + halfSpread
-
-	return []Signal{
-		{
-			MarketID:     m.ID,
-			Outcome:      "YES",
-			Confidence:   m.Probability,
-			MarketProb:   m.Probability,
-			Edge:         halfSpread,
-			Strategy:     "marketmaking",
-			Reason:       fmt.Sprintf("bid YES at %.3f (market %.3f, spread %.3f)", yesBuyProb, m.Probability, spread),
-			IsLimitOrder: true,
-			LimitProb:    yesBuyProb,
-		},
-		{
-			MarketID:     m.ID,
-			Outcome:      "NO",
-			Confidence:   1 - m.Probability,
-			MarketProb:   m.Probability,
-			Edge:         halfSpread,
-			Strategy:     "marketmaking",
-			Reason:       fmt.Sprintf("ask NO at %.3f (market %.3f, spread %.3f)", noBuyProb, m.Probability, spread),
-			IsLimitOrder: true,
-			LimitProb:    noBuyProb,
-		},
+	rawMultipliers := make([]float64, numLayers)
+	var totalMultiplier float64
+	for i := 0; i < numLayers; i++ {
+		if strings.EqualFold(mm.cfg.LayerSizeMode, "arithmetic") {
+			rawMultipliers[i] = 1 + float64(i)*(quantityMultiplier-1)
+		} else {
+			rawMultipliers[i] = math.Pow(quantityMultiplier, float64(i))
+		}
+		totalMultiplier += rawMultipliers[i]
 	}
+
+	// Scale the ladder down if its summed SizeMultiplier would exceed
+	// maxMultiplier, a ceiling that grows with the market's own liquidity.
+	//
+	// NOTE: despite the "CapitalPct" name, this is NOT a true mana budget.
+	// totalMultiplier is a dimensionless sum of per-layer ratios; the actual
+	// mana amount per layer isn't known until risk.Manager.sizePosition
+	// resolves it later via Kelly sizing against portfolio balance, which
+	// this strategy has no visibility into at signal-generation time. So
+	// maxMultiplier below is liquidity-scaled units of "multiplier", not
+	// mana — comparing it to totalMultiplier is an intentional heuristic
+	// (bigger markets can support a bigger ladder), not a capital cap.
+	// risk.Manager's own MaxMarketExposurePct (via CapToMarketExposure) is
+	// what actually bounds mana committed to a market.
+	scale := 1.0
+	if mm.cfg.MaxLimitOrderCapitalPct > 0 {
+		maxMultiplier := mm.cfg.MaxLimitOrderCapitalPct * m.TotalLiquidity
+		if totalMultiplier > maxMultiplier && maxMultiplier > 0 {
+			scale = maxMultiplier / totalMultiplier
+		}
+	}
+
+	signals := make([]Signal, 0, 2*numLayers)
+	for i := 0; i < numLayers; i++ {
+		offset := halfSpread + float64(i)*mm.cfg.LayerSpread
+		sizeMultiplier := rawMultipliers[i] * scale
+
+		yesBuyProb := m.Probability - offset
+		noBuyProb := m.Probability + offset
+
+		signals = append(signals,
+			Signal{
+				MarketID:       m.ID,
+				Outcome:        "YES",
+				Confidence:     m.Probability,
+				MarketProb:     m.Probability,
+				Edge:           offset,
+				Strategy:       "marketmaking",
+				Reason:         fmt.Sprintf("bid YES at %.3f (layer %d, market %.3f, spread %.3f)", yesBuyProb, i, m.Probability, spread),
+				IsLimitOrder:   true,
+				LimitProb:      yesBuyProb,
+				SizeMultiplier: sizeMultiplier,
+				LayerIndex:     i,
+			},
+			Signal{
+				MarketID:       m.ID,
+				Outcome:        "NO",
+				Confidence:     1 - m.Probability,
+				MarketProb:     m.Probability,
+				Edge:           offset,
+				Strategy:       "marketmaking",
+				Reason:         fmt.Sprintf("ask NO at %.3f (layer %d, market %.3f, spread %.3f)", noBuyProb, i, m.Probability, spread),
+				IsLimitOrder:   true,
+				LimitProb:      noBuyProb,
+				SizeMultiplier: sizeMultiplier,
+				LayerIndex:     i,
+			},
+		)
+	}
+
+	return signals
 }
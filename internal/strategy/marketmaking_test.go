@@ -10,10 +10,12 @@ import (
 
 func newMarketMakingConfig() config.MarketMakingConfig {
 	return config.MarketMakingConfig{
-		Enabled:      true,
-		BaseSpread:   0.04,
-		MinLiquidity: 500,
-		MinVolume24h: 50,
+		Enabled:            true,
+		BaseSpread:         0.04,
+		MinLiquidity:       500,
+		MinVolume24h:       50,
+		NumLayers:          1,
+		QuantityMultiplier: 1.0,
 	}
 }
 
@@ -59,6 +61,142 @@ func TestMarketMaking_GeneratesTwoSignals(t *testing.T) {
 	}
 }
 
+func TestMarketMaking_LaddersMultipleLayers(t *testing.T) {
+	cfg := newMarketMakingConfig()
+	cfg.NumLayers = 3
+	cfg.LayerSpread = 0.01
+	cfg.QuantityMultiplier = 1.5
+	mm := NewMarketMaking(cfg)
+
+	markets := []MarketData{
+		{
+			ID:             "ladder-1",
+			OutcomeType:    "BINARY",
+			Probability:    0.50,
+			TotalLiquidity: 600,
+			Volume24Hours:  100,
+			CloseTime:      time.Now().Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := mm.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 2*cfg.NumLayers {
+		t.Fatalf("expected %d signals (3 layers x 2 sides), got %d", 2*cfg.NumLayers, len(signals))
+	}
+
+	// Layer 0 uses cfg.BaseSpread (0.04) since liquidity is below the 1000
+	// tightening threshold; half spread is 0.02.
+	wantOffsets := []float64{0.02, 0.03, 0.04}
+	wantMultipliers := []float64{1.0, 1.5, 2.25}
+
+	for i, offset := range wantOffsets {
+		var gotYes, gotNo bool
+		for _, sig := range signals {
+			if sig.Edge != offset {
+				continue
+			}
+			if sig.LayerIndex != i {
+				t.Errorf("layer %d: expected LayerIndex %d, got %d", i, i, sig.LayerIndex)
+			}
+			if sig.Outcome == "YES" {
+				gotYes = true
+				if sig.SizeMultiplier != wantMultipliers[i] {
+					t.Errorf("layer %d: expected size multiplier %f, got %f", i, wantMultipliers[i], sig.SizeMultiplier)
+				}
+			}
+			if sig.Outcome == "NO" {
+				gotNo = true
+			}
+		}
+		if !gotYes || !gotNo {
+			t.Errorf("layer %d (offset %f): missing YES or NO signal", i, offset)
+		}
+	}
+}
+
+func TestMarketMaking_ArithmeticLayerSizeMode(t *testing.T) {
+	cfg := newMarketMakingConfig()
+	cfg.NumLayers = 3
+	cfg.LayerSpread = 0.01
+	cfg.QuantityMultiplier = 1.5
+	cfg.LayerSizeMode = "arithmetic"
+	mm := NewMarketMaking(cfg)
+
+	markets := []MarketData{
+		{
+			ID:             "arith-1",
+			OutcomeType:    "BINARY",
+			Probability:    0.50,
+			TotalLiquidity: 600,
+			Volume24Hours:  100,
+			CloseTime:      time.Now().Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := mm.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Arithmetic ramp: layer i gets 1+i*(QuantityMultiplier-1) = 1, 1.5, 2.0.
+	wantOffsets := []float64{0.02, 0.03, 0.04}
+	wantMultipliers := []float64{1.0, 1.5, 2.0}
+	for i, offset := range wantOffsets {
+		for _, sig := range signals {
+			if sig.Edge != offset || sig.Outcome != "YES" {
+				continue
+			}
+			if sig.SizeMultiplier != wantMultipliers[i] {
+				t.Errorf("layer %d: expected size multiplier %f, got %f", i, wantMultipliers[i], sig.SizeMultiplier)
+			}
+		}
+	}
+}
+
+func TestMarketMaking_CapsTotalLayerCapital(t *testing.T) {
+	cfg := newMarketMakingConfig()
+	cfg.NumLayers = 5
+	cfg.LayerSpread = 0.01
+	cfg.QuantityMultiplier = 2.0
+	cfg.MaxLimitOrderCapitalPct = 0.02 // Tight cap to force scaling down.
+	mm := NewMarketMaking(cfg)
+
+	markets := []MarketData{
+		{
+			ID:             "capped-1",
+			OutcomeType:    "BINARY",
+			Probability:    0.50,
+			TotalLiquidity: 600,
+			Volume24Hours:  100,
+			CloseTime:      time.Now().Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := mm.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uncappedTotal, cappedTotal float64
+	multiplier := 1.0
+	for i := 0; i < cfg.NumLayers; i++ {
+		uncappedTotal += multiplier
+		multiplier *= cfg.QuantityMultiplier
+	}
+	for _, sig := range signals {
+		if sig.Outcome == "YES" {
+			cappedTotal += sig.SizeMultiplier
+		}
+	}
+
+	if cappedTotal >= uncappedTotal {
+		t.Errorf("expected capped total (%f) to be less than uncapped total (%f)", cappedTotal, uncappedTotal)
+	}
+}
+
 func TestMarketMaking_SkipsLowLiquidity(t *testing.T) {
 	mm := NewMarketMaking(newMarketMakingConfig())
 
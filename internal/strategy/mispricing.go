@@ -2,8 +2,10 @@ package strategy
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
+	"math"
 	"time"
 
 	"hyperkaehler/internal/config"
@@ -12,16 +14,33 @@ import (
 // Mispricing finds binary markets where the probability is likely mispriced,
 // either through extreme probability confirmation or mean reversion on sudden moves.
 type Mispricing struct {
-	cfg config.MispricingConfig
+	cfg       config.MispricingConfig
+	snapshots *SnapshotRepository
+	cooldowns map[string]time.Time // marketID -> earliest time a reversion signal may fire again
+	breaker   CircuitBreaker
+	perfStats PerformanceStats
 }
 
-func NewMispricing(cfg config.MispricingConfig) *Mispricing {
-	return &Mispricing{cfg: cfg}
+func NewMispricing(cfg config.MispricingConfig, db *sql.DB) *Mispricing {
+	return &Mispricing{
+		cfg:       cfg,
+		snapshots: NewSnapshotRepository(db),
+		cooldowns: make(map[string]time.Time),
+	}
 }
 
 func (m *Mispricing) Name() string  { return "mispricing" }
 func (m *Mispricing) Enabled() bool { return m.cfg.Enabled }
 
+// SetCircuitBreaker wires a CircuitBreaker that Evaluate consults before
+// returning signals. A nil breaker (the default) disables the check.
+func (m *Mispricing) SetCircuitBreaker(cb CircuitBreaker) { m.breaker = cb }
+
+// SetPerformanceStats wires a PerformanceStats that Evaluate consults to
+// scale signals by this strategy's realized track record. A nil stats
+// (the default) disables the adjustment.
+func (m *Mispricing) SetPerformanceStats(stats PerformanceStats) { m.perfStats = stats }
+
 func (m *Mispricing) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
 	var signals []Signal
 	now := time.Now()
@@ -37,14 +56,13 @@ func (m *Mispricing) Evaluate(_ context.Context, markets []MarketData) ([]Signal
 		sigs := m.evaluateExtreme(mkt, now)
 		signals = append(signals, sigs...)
 
-		// TODO: Sub-strategy B (mean reversion on sudden moves).
-		// Requires historical probability snapshots to compare current
-		// probability against a recent previous value. Implement once
-		// the snapshot store is available in-strategy.
+		sigs = m.evaluateReversion(mkt, now)
+		signals = append(signals, sigs...)
 	}
 
 	slog.Info("mispricing evaluation complete", "signals", len(signals))
-	return signals, nil
+	signals = applyCircuitBreaker(m.breaker, m.Name(), signals)
+	return applyPerformanceStats(m.perfStats, m.Name(), signals), nil
 }
 
 // evaluateExtreme implements sub-strategy A: extreme probability confirmation.
@@ -109,3 +127,95 @@ func (m *Mispricing) evaluateExtreme(mkt MarketData, now time.Time) []Signal {
 		Reason:     fmt.Sprintf("extreme probability confirmation: market at %.2f, betting %s with confidence %.2f", prob, outcome, confidence),
 	}}
 }
+
+// evaluateReversion implements sub-strategy B: mean reversion on sudden
+// moves. A market whose probability jumped by more than SuddenMoveThreshold
+// within SuddenMoveWindow, without volume confirming the move, is more
+// likely an overreaction than new information — so we post a limit order
+// betting it reverts partway back toward the pre-move probability.
+func (m *Mispricing) evaluateReversion(mkt MarketData, now time.Time) []Signal {
+	if until, cooling := m.cooldowns[mkt.ID]; cooling && now.Before(until) {
+		return nil
+	}
+
+	window := m.cfg.SuddenMoveWindow.Duration
+	if window <= 0 || m.cfg.SuddenMoveThreshold <= 0 {
+		return nil
+	}
+	since := now.Add(-window)
+
+	priorProb, ok, err := m.snapshots.ProbabilityAfter(mkt.ID, since)
+	if err != nil {
+		slog.Warn("mispricing: failed to load prior probability", "market", mkt.ID, "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	move := mkt.Probability - priorProb
+	absMove := math.Abs(move)
+	if absMove < m.cfg.SuddenMoveThreshold {
+		return nil
+	}
+
+	volume, err := m.snapshots.VolumeSince(mkt.ID, since, mkt.Volume)
+	if err != nil {
+		slog.Warn("mispricing: failed to load confirming volume", "market", mkt.ID, "error", err)
+		return nil
+	}
+	if volume >= m.cfg.MinVolumeConfirm {
+		// Volume confirms the move looks like genuine new information, not
+		// a mispricing to revert.
+		return nil
+	}
+
+	// Bet against the move: a sudden rise is faded with NO, a sudden drop
+	// with YES.
+	outcome := "NO"
+	if move < 0 {
+		outcome = "YES"
+	}
+	limitProb := (mkt.Probability + priorProb) / 2
+
+	// Confidence scales from 0.5 (just past the threshold) toward 0.9 as the
+	// move grows to 2x the threshold or beyond.
+	excess := (absMove - m.cfg.SuddenMoveThreshold) / m.cfg.SuddenMoveThreshold
+	confidence := 0.5 + 0.4*math.Min(excess, 1.0)
+
+	var edge float64
+	if outcome == "YES" {
+		edge = confidence - limitProb
+	} else {
+		edge = confidence - (1 - limitProb)
+	}
+	if edge <= 0 {
+		return nil
+	}
+
+	if m.cfg.ReversionCooldown.Duration > 0 {
+		m.cooldowns[mkt.ID] = now.Add(m.cfg.ReversionCooldown.Duration)
+	}
+
+	slog.Debug("mean reversion candidate",
+		"market", mkt.ID,
+		"question", mkt.Question,
+		"prior_prob", priorProb,
+		"current_prob", mkt.Probability,
+		"move", move,
+		"confirming_volume", volume,
+		"outcome", outcome,
+	)
+
+	return []Signal{{
+		MarketID:     mkt.ID,
+		Outcome:      outcome,
+		Confidence:   confidence,
+		MarketProb:   mkt.Probability,
+		Edge:         edge,
+		Strategy:     "mispricing",
+		Reason:       fmt.Sprintf("mean reversion: moved %.2f to %.2f in window without confirming volume, fading to %s at %.2f", priorProb, mkt.Probability, outcome, limitProb),
+		IsLimitOrder: true,
+		LimitProb:    limitProb,
+	}}
+}
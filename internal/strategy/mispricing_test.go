@@ -2,10 +2,12 @@ package strategy
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
 )
 
 func newMispricingConfig() config.MispricingConfig {
@@ -18,8 +20,38 @@ func newMispricingConfig() config.MispricingConfig {
 	}
 }
 
+func newTestMispricingDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func insertSnapshot(t *testing.T, database *sql.DB, marketID string, probability, volume float64, at time.Time) {
+	t.Helper()
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES (?, 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')
+		ON CONFLICT(id) DO NOTHING`, marketID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`
+		INSERT INTO market_snapshots (market_id, probability, volume, volume_24h, total_liquidity, snapshot_at)
+		VALUES (?, ?, ?, 0, 0, ?)`,
+		marketID, probability, volume, at.UTC().Format("2006-01-02 15:04:05"),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestMispricing_HighExtreme(t *testing.T) {
-	m := NewMispricing(newMispricingConfig())
+	m := NewMispricing(newMispricingConfig(), newTestMispricingDB(t))
 	markets := []MarketData{
 		{
 			ID:          "high-1",
@@ -47,7 +79,7 @@ func TestMispricing_HighExtreme(t *testing.T) {
 }
 
 func TestMispricing_LowExtreme(t *testing.T) {
-	m := NewMispricing(newMispricingConfig())
+	m := NewMispricing(newMispricingConfig(), newTestMispricingDB(t))
 	markets := []MarketData{
 		{
 			ID:          "low-1",
@@ -72,7 +104,7 @@ func TestMispricing_LowExtreme(t *testing.T) {
 }
 
 func TestMispricing_SkipsTooNew(t *testing.T) {
-	m := NewMispricing(newMispricingConfig())
+	m := NewMispricing(newMispricingConfig(), newTestMispricingDB(t))
 	markets := []MarketData{
 		{
 			ID:          "new-1",
@@ -94,7 +126,7 @@ func TestMispricing_SkipsTooNew(t *testing.T) {
 }
 
 func TestMispricing_SkipsLowVolume(t *testing.T) {
-	m := NewMispricing(newMispricingConfig())
+	m := NewMispricing(newMispricingConfig(), newTestMispricingDB(t))
 	markets := []MarketData{
 		{
 			ID:          "lowvol-1",
@@ -116,7 +148,7 @@ func TestMispricing_SkipsLowVolume(t *testing.T) {
 }
 
 func TestMispricing_SkipsCloseToExpiry(t *testing.T) {
-	m := NewMispricing(newMispricingConfig())
+	m := NewMispricing(newMispricingConfig(), newTestMispricingDB(t))
 	markets := []MarketData{
 		{
 			ID:          "closing-1",
@@ -136,3 +168,132 @@ func TestMispricing_SkipsCloseToExpiry(t *testing.T) {
 		t.Errorf("expected 0 signals for near-close market, got %d", len(signals))
 	}
 }
+
+func newReversionConfig() config.MispricingConfig {
+	cfg := newMispricingConfig()
+	cfg.SuddenMoveThreshold = 0.15
+	cfg.SuddenMoveWindow = config.Duration{Duration: 24 * time.Hour}
+	cfg.MinVolumeConfirm = 500
+	cfg.ReversionCooldown = config.Duration{Duration: 12 * time.Hour}
+	return cfg
+}
+
+func TestMispricing_ReversionFiresOnUnconfirmedSuddenMove(t *testing.T) {
+	database := newTestMispricingDB(t)
+	now := time.Now()
+	insertSnapshot(t, database, "m1", 0.40, 1000, now.Add(-23*time.Hour))
+
+	m := NewMispricing(newReversionConfig(), database)
+	markets := []MarketData{
+		{
+			ID:          "m1",
+			OutcomeType: "BINARY",
+			Probability: 0.60, // +0.20 move, above the 0.15 threshold.
+			Volume:      1050, // Only 50 traded since the move — below MinVolumeConfirm.
+			CreatedTime: now.Add(-60 * 24 * time.Hour),
+			CloseTime:   now.Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := m.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 reversion signal, got %d", len(signals))
+	}
+	sig := signals[0]
+	if sig.Outcome != "NO" {
+		t.Errorf("expected NO (fading the rise), got %s", sig.Outcome)
+	}
+	if !sig.IsLimitOrder {
+		t.Error("expected a limit order")
+	}
+	if sig.LimitProb != 0.50 {
+		t.Errorf("expected limit prob at the midpoint 0.50, got %f", sig.LimitProb)
+	}
+}
+
+func TestMispricing_ReversionSkipsWhenVolumeConfirmsMove(t *testing.T) {
+	database := newTestMispricingDB(t)
+	now := time.Now()
+	insertSnapshot(t, database, "m1", 0.40, 1000, now.Add(-23*time.Hour))
+
+	m := NewMispricing(newReversionConfig(), database)
+	markets := []MarketData{
+		{
+			ID:          "m1",
+			OutcomeType: "BINARY",
+			Probability: 0.60,
+			Volume:      2000, // 1000 traded since the move — meets MinVolumeConfirm.
+			CreatedTime: now.Add(-60 * 24 * time.Hour),
+			CloseTime:   now.Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := m.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected 0 signals when volume confirms the move, got %d", len(signals))
+	}
+}
+
+func TestMispricing_ReversionSkipsBelowThreshold(t *testing.T) {
+	database := newTestMispricingDB(t)
+	now := time.Now()
+	insertSnapshot(t, database, "m1", 0.40, 1000, now.Add(-23*time.Hour))
+
+	m := NewMispricing(newReversionConfig(), database)
+	markets := []MarketData{
+		{
+			ID:          "m1",
+			OutcomeType: "BINARY",
+			Probability: 0.48, // Only +0.08, below the 0.15 threshold.
+			Volume:      1010,
+			CreatedTime: now.Add(-60 * 24 * time.Hour),
+			CloseTime:   now.Add(60 * 24 * time.Hour),
+		},
+	}
+
+	signals, err := m.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected 0 signals below the move threshold, got %d", len(signals))
+	}
+}
+
+func TestMispricing_ReversionRespectsCooldown(t *testing.T) {
+	database := newTestMispricingDB(t)
+	now := time.Now()
+	insertSnapshot(t, database, "m1", 0.40, 1000, now.Add(-23*time.Hour))
+
+	m := NewMispricing(newReversionConfig(), database)
+	market := MarketData{
+		ID:          "m1",
+		OutcomeType: "BINARY",
+		Probability: 0.60,
+		Volume:      1050,
+		CreatedTime: now.Add(-60 * 24 * time.Hour),
+		CloseTime:   now.Add(60 * 24 * time.Hour),
+	}
+
+	first, err := m.Evaluate(context.Background(), []MarketData{market})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("expected 1 signal on first evaluation, got %d", len(first))
+	}
+
+	second, err := m.Evaluate(context.Background(), []MarketData{market})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 0 {
+		t.Errorf("expected cooldown to suppress a repeat signal, got %d", len(second))
+	}
+}
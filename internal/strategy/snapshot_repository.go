@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SnapshotRepository answers historical probability/volume questions about a
+// market from the market_snapshots table, for strategies that need to
+// compare current state against a recent past state rather than just the
+// latest scan (e.g. Mispricing's mean-reversion sub-strategy).
+type SnapshotRepository struct {
+	db *sql.DB
+}
+
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// ProbabilityBefore returns the most recently recorded probability for
+// marketID at or before the given instant, and ok=false if no such snapshot
+// exists.
+func (s *SnapshotRepository) ProbabilityBefore(marketID string, before time.Time) (float64, bool, error) {
+	var prob sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT probability FROM market_snapshots
+		WHERE market_id = ? AND snapshot_at <= ? AND probability IS NOT NULL
+		ORDER BY snapshot_at DESC LIMIT 1`,
+		marketID, before.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&prob)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return prob.Float64, prob.Valid, nil
+}
+
+// ProbabilityAfter returns the earliest recorded probability for marketID
+// at or after the given instant, and ok=false if no such snapshot exists.
+// This is the pre-move baseline for a window lookback: the snapshot closest
+// to (but not before) the start of the window, rather than one already
+// stale before the window began.
+func (s *SnapshotRepository) ProbabilityAfter(marketID string, since time.Time) (float64, bool, error) {
+	var prob sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT probability FROM market_snapshots
+		WHERE market_id = ? AND snapshot_at >= ? AND probability IS NOT NULL
+		ORDER BY snapshot_at ASC LIMIT 1`,
+		marketID, since.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&prob)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return prob.Float64, prob.Valid, nil
+}
+
+// VolumeSince returns the increase in cumulative volume for marketID since
+// the earliest snapshot at or after `since` (the baseline), measured
+// against currentVolume — the market's live, just-fetched volume rather
+// than another recorded snapshot. Callers evaluating a just-scanned market
+// already have a fresher volume reading than anything in market_snapshots,
+// and a snapshot-to-snapshot delta would understate (or, with only one
+// snapshot recorded, always zero out) the volume that's confirmed a move.
+func (s *SnapshotRepository) VolumeSince(marketID string, since time.Time, currentVolume float64) (float64, error) {
+	var baseline sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT volume FROM market_snapshots
+		WHERE market_id = ? AND snapshot_at >= ?
+		ORDER BY snapshot_at ASC LIMIT 1`,
+		marketID, since.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&baseline)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	delta := currentVolume - baseline.Float64
+	if delta < 0 {
+		return 0, nil
+	}
+	return delta, nil
+}
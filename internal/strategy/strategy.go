@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"context"
+	"log/slog"
 	"time"
 )
 
@@ -17,6 +18,38 @@ type Signal struct {
 	Reason       string
 	IsLimitOrder bool
 	LimitProb    float64
+	// SizeMultiplier scales the risk manager's base Kelly sizing for this
+	// signal. 0 (the zero value) is treated as 1.0, i.e. no scaling — most
+	// strategies can leave it unset. Used by layered strategies such as
+	// MarketMaking where deeper layers should post larger size.
+	SizeMultiplier float64
+	// IsExit marks a signal that closes an existing position rather than
+	// opening a new one (e.g. a trailing-stop exit). Outcome is set to the
+	// opposite side of the original bet.
+	IsExit bool
+	// HedgePairID, when non-empty, marks this signal as one leg of a pair
+	// that must be executed atomically: both legs are placed or neither is.
+	// Signals sharing the same HedgePairID are siblings (e.g. the two sides
+	// of a CrossMarketHedge); if one leg fails to place, the executor
+	// cancels the other rather than leaving a naked position.
+	HedgePairID string
+	// LayerIndex is which rung of a laddered strategy's order book this
+	// signal belongs to (0 for the innermost layer, or for strategies that
+	// don't ladder at all). Persisted alongside the bet so backtests can
+	// measure fill quality per rung.
+	LayerIndex int
+	// SignalNumber is a continuous, signed conviction score in [-1, +1]:
+	// sign is direction (positive leans YES, negative leans NO) and
+	// magnitude is conviction strength. risk.Manager.SizeSignals nets
+	// SignalNumber across strategies sharing a market before Kelly sizing,
+	// so strategies that don't have a natural notion of direction/magnitude
+	// beyond Confidence/Edge can leave it at the zero value.
+	SignalNumber float64
+	// GeneratedAt is when the strategy produced this signal. Zero means
+	// "just generated" — risk.Manager.SizeSignals applies no staleness
+	// decay to it. Set by strategies that buffer signals across scan
+	// cycles so SizeSignals can down-weight stale ones.
+	GeneratedAt time.Time
 }
 
 // Strategy is the interface all trading strategies must implement.
@@ -26,6 +59,89 @@ type Strategy interface {
 	Enabled() bool
 }
 
+// CircuitBreaker is the per-strategy view of risk.BetHistoryCircuitBreaker
+// that strategies consult before emitting signals. It is declared here,
+// rather than importing internal/risk directly, because risk already depends
+// on strategy.Signal — *risk.BetHistoryCircuitBreaker satisfies this
+// interface (the risk.CircuitBreaker interface itself has no StrategyStatus)
+// without either package needing to know about the other's concrete type.
+type CircuitBreaker interface {
+	// StrategyStatus reports whether strategyName should hold back or
+	// downscale its signals. downscale is only meaningful when
+	// tripped is true: 0 means skip evaluation entirely, >0 is the
+	// factor to multiply Confidence and Edge by instead.
+	StrategyStatus(strategyName string) (tripped bool, downscale float64)
+}
+
+// PerformanceStats is the subset of performance.TradeStatsTracker that
+// strategies consult to scale fresh signals by realized track record. It is
+// declared here, mirroring CircuitBreaker, so strategy doesn't need to
+// import performance.
+type PerformanceStats interface {
+	// StrategyMultiplier returns the fractional-Kelly scaling factor
+	// strategyName's signals should be sized by, derived from its rolling
+	// TradeStats. 1.0 means no adjustment (insufficient history).
+	StrategyMultiplier(strategyName string) float64
+}
+
+// applyPerformanceStats scales signals' SizeMultiplier by stats' reported
+// fractional-Kelly multiplier for name. A nil stats, or a multiplier of
+// 1.0, is a no-op, so strategies can leave it unset in tests.
+func applyPerformanceStats(stats PerformanceStats, name string, signals []Signal) []Signal {
+	if stats == nil || len(signals) == 0 {
+		return signals
+	}
+
+	mult := stats.StrategyMultiplier(name)
+	if mult == 1.0 {
+		return signals
+	}
+
+	scaled := make([]Signal, len(signals))
+	for i, sig := range signals {
+		base := sig.SizeMultiplier
+		if base <= 0 {
+			base = 1.0
+		}
+		sig.SizeMultiplier = base * mult
+		scaled[i] = sig
+	}
+	if mult <= 0 {
+		slog.Info("performance stats: zeroing signals, profit factor below 1.0", "strategy", name, "signals", len(signals))
+	} else {
+		slog.Info("performance stats: scaling signals by realized edge accuracy", "strategy", name, "factor", mult, "signals", len(signals))
+	}
+	return scaled
+}
+
+// applyCircuitBreaker gates signals against breaker's reported status for
+// name, skipping them entirely when tripped with no downscale factor, or
+// scaling Confidence and Edge down when one is set. A nil breaker is a
+// no-op, so strategies can leave it unset in tests.
+func applyCircuitBreaker(breaker CircuitBreaker, name string, signals []Signal) []Signal {
+	if breaker == nil || len(signals) == 0 {
+		return signals
+	}
+
+	tripped, downscale := breaker.StrategyStatus(name)
+	if !tripped {
+		return signals
+	}
+	if downscale <= 0 {
+		slog.Info("circuit breaker tripped: skipping signals", "strategy", name, "signals", len(signals))
+		return nil
+	}
+
+	scaled := make([]Signal, len(signals))
+	for i, sig := range signals {
+		sig.Confidence *= downscale
+		sig.Edge *= downscale
+		scaled[i] = sig
+	}
+	slog.Info("circuit breaker tripped: downscaling signals", "strategy", name, "factor", downscale, "signals", len(signals))
+	return scaled
+}
+
 // MarketData is a unified view of a market that strategies consume.
 type MarketData struct {
 	ID              string
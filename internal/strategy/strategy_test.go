@@ -0,0 +1,45 @@
+package strategy
+
+import "testing"
+
+type fakeCircuitBreaker struct {
+	tripped   bool
+	downscale float64
+}
+
+func (f fakeCircuitBreaker) StrategyStatus(string) (bool, float64) { return f.tripped, f.downscale }
+
+func TestApplyCircuitBreaker_NilBreakerIsNoOp(t *testing.T) {
+	signals := []Signal{{Confidence: 0.8, Edge: 0.1}}
+	out := applyCircuitBreaker(nil, "mispricing", signals)
+	if len(out) != 1 || out[0].Confidence != 0.8 {
+		t.Fatalf("expected signals unchanged with nil breaker, got %+v", out)
+	}
+}
+
+func TestApplyCircuitBreaker_NotTrippedPassesThrough(t *testing.T) {
+	signals := []Signal{{Confidence: 0.8, Edge: 0.1}}
+	out := applyCircuitBreaker(fakeCircuitBreaker{tripped: false}, "mispricing", signals)
+	if len(out) != 1 || out[0].Confidence != 0.8 {
+		t.Fatalf("expected signals unchanged when not tripped, got %+v", out)
+	}
+}
+
+func TestApplyCircuitBreaker_TrippedWithNoDownscaleDropsSignals(t *testing.T) {
+	signals := []Signal{{Confidence: 0.8, Edge: 0.1}}
+	out := applyCircuitBreaker(fakeCircuitBreaker{tripped: true, downscale: 0}, "mispricing", signals)
+	if out != nil {
+		t.Fatalf("expected signals dropped entirely, got %+v", out)
+	}
+}
+
+func TestApplyCircuitBreaker_TrippedWithDownscaleScalesConfidenceAndEdge(t *testing.T) {
+	signals := []Signal{{Confidence: 0.8, Edge: 0.2}}
+	out := applyCircuitBreaker(fakeCircuitBreaker{tripped: true, downscale: 0.5}, "mispricing", signals)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 downscaled signal, got %d", len(out))
+	}
+	if out[0].Confidence != 0.4 || out[0].Edge != 0.1 {
+		t.Errorf("expected Confidence and Edge halved, got %+v", out[0])
+	}
+}
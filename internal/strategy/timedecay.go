@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"regexp"
@@ -10,6 +11,10 @@ import (
 	"hyperkaehler/internal/config"
 )
 
+// defaultCalibrationInterval is the fallback refit cadence when
+// config.TimeDecayConfig.CalibrationInterval is unset.
+const defaultCalibrationInterval = 1 * time.Hour
+
 var timePatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)by (January|February|March|April|May|June|July|August|September|October|November|December) (\d{4})`),
 	regexp.MustCompile(`(?i)before (January|February|March|April|May|June|July|August|September|October|November|December) \d{1,2}`),
@@ -20,21 +25,31 @@ var timePatterns = []*regexp.Regexp{
 
 // TimeDecay targets "Will X happen by DATE?" markets that should decay toward NO as time passes.
 type TimeDecay struct {
-	cfg config.TimeDecayConfig
+	cfg        config.TimeDecayConfig
+	breaker    CircuitBreaker
+	calibrator *DecayCalibrator
+
+	curve         []DecileCalibration
+	curveFittedAt time.Time
 }
 
-func NewTimeDecay(cfg config.TimeDecayConfig) *TimeDecay {
-	return &TimeDecay{cfg: cfg}
+func NewTimeDecay(cfg config.TimeDecayConfig, db *sql.DB) *TimeDecay {
+	return &TimeDecay{cfg: cfg, calibrator: NewDecayCalibrator(db)}
 }
 
 func (t *TimeDecay) Name() string  { return "timedecay" }
 func (t *TimeDecay) Enabled() bool { return t.cfg.Enabled }
 
+// SetCircuitBreaker wires a CircuitBreaker that Evaluate consults before
+// returning signals. A nil breaker (the default) disables the check.
+func (t *TimeDecay) SetCircuitBreaker(cb CircuitBreaker) { t.breaker = cb }
+
 func (t *TimeDecay) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
 	var signals []Signal
 	evaluated := 0
 
 	now := time.Now()
+	t.refreshCurve(now)
 
 	for _, m := range markets {
 		if m.OutcomeType != "BINARY" {
@@ -62,7 +77,54 @@ func (t *TimeDecay) Evaluate(_ context.Context, markets []MarketData) ([]Signal,
 	}
 
 	slog.Info("timedecay evaluation complete", "markets_evaluated", evaluated, "signals", len(signals))
-	return signals, nil
+	return applyCircuitBreaker(t.breaker, t.Name(), signals), nil
+}
+
+// refreshCurve refits the decay curve from resolved-market history at most
+// once per CalibrationInterval while a curve is held; while t.curve is nil
+// (as on a fresh process, or after a refit that found nothing) it retries on
+// every call. Whenever a live refit yields no curve it falls back to
+// whatever was last persisted, so a transient DB error on startup doesn't
+// leave evaluateMarket running uncalibrated for a full interval.
+func (t *TimeDecay) refreshCurve(now time.Time) {
+	interval := t.cfg.CalibrationInterval.Duration
+	if interval <= 0 {
+		interval = defaultCalibrationInterval
+	}
+	if t.curve != nil && now.Sub(t.curveFittedAt) < interval {
+		return
+	}
+
+	curve, err := t.calibrator.Calibrate()
+	if err != nil {
+		slog.Warn("timedecay: calibration refit failed", "error", err)
+		curve = nil
+	}
+	if len(curve) == 0 {
+		// A live refit with no (or failed) resolved-market history shouldn't
+		// discard a curve a previous process already fit and persisted.
+		if loaded, lerr := t.calibrator.Load(); lerr == nil && len(loaded) > 0 {
+			curve = loaded
+		}
+	}
+	t.curve = curve
+	t.curveFittedAt = now
+}
+
+// calibratedEstimate returns the calibrated decay curve's estimate for
+// marketProb at elapsedFraction, and false if the curve's SampleSize summed
+// across deciles is below cfg.MinCalibrationSamples. SampleSize counts
+// resolved-market snapshots, so a market with several snapshots counts once
+// per snapshot rather than once per market.
+func (t *TimeDecay) calibratedEstimate(marketProb, elapsedFraction float64) (float64, bool) {
+	total := 0
+	for _, d := range t.curve {
+		total += d.SampleSize
+	}
+	if total < t.cfg.MinCalibrationSamples {
+		return 0, false
+	}
+	return estimateFromCurve(t.curve, marketProb, elapsedFraction)
 }
 
 func matchesTimePattern(question string) bool {
@@ -92,7 +154,12 @@ func (t *TimeDecay) evaluateMarket(m MarketData, now time.Time) (Signal, bool) {
 		timeElapsedFraction = 1.0
 	}
 
-	estimatedProb := m.Probability * (1 - timeElapsedFraction*0.5)
+	estimatedProb, ok := t.calibratedEstimate(m.Probability, timeElapsedFraction)
+	if !ok {
+		// Not enough resolved-market history to trust a calibrated curve
+		// yet: fall back to the original hard-coded linear decay.
+		estimatedProb = m.Probability * (1 - timeElapsedFraction*0.5)
+	}
 	edge := m.Probability - estimatedProb
 
 	if edge <= t.cfg.MinEdge {
@@ -2,10 +2,12 @@ package strategy
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
 )
 
 func newTimeDecayConfig() config.TimeDecayConfig {
@@ -17,8 +19,21 @@ func newTimeDecayConfig() config.TimeDecayConfig {
 	}
 }
 
+func newTestTimeDecayDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
 func TestTimeDecay_GeneratesSignal(t *testing.T) {
-	td := NewTimeDecay(newTimeDecayConfig())
+	td := NewTimeDecay(newTimeDecayConfig(), newTestTimeDecayDB(t))
 
 	// Market 80% through its duration, at 40% probability, with time-related question.
 	now := time.Now()
@@ -53,7 +68,7 @@ func TestTimeDecay_GeneratesSignal(t *testing.T) {
 }
 
 func TestTimeDecay_SkipsNoTimePattern(t *testing.T) {
-	td := NewTimeDecay(newTimeDecayConfig())
+	td := NewTimeDecay(newTimeDecayConfig(), newTestTimeDecayDB(t))
 
 	markets := []MarketData{
 		{
@@ -77,7 +92,7 @@ func TestTimeDecay_SkipsNoTimePattern(t *testing.T) {
 }
 
 func TestTimeDecay_SkipsHighProbability(t *testing.T) {
-	td := NewTimeDecay(newTimeDecayConfig())
+	td := NewTimeDecay(newTimeDecayConfig(), newTestTimeDecayDB(t))
 
 	markets := []MarketData{
 		{
@@ -101,7 +116,7 @@ func TestTimeDecay_SkipsHighProbability(t *testing.T) {
 }
 
 func TestTimeDecay_SkipsEarlyMarket(t *testing.T) {
-	td := NewTimeDecay(newTimeDecayConfig())
+	td := NewTimeDecay(newTimeDecayConfig(), newTestTimeDecayDB(t))
 
 	// Only 20% through duration.
 	now := time.Now()
@@ -128,3 +143,82 @@ func TestTimeDecay_SkipsEarlyMarket(t *testing.T) {
 		t.Errorf("expected 0 signals for early market, got %d", len(signals))
 	}
 }
+
+func TestTimeDecay_FallsBackToLinearDecayBelowMinCalibrationSamples(t *testing.T) {
+	cfg := newTimeDecayConfig()
+	cfg.MinCalibrationSamples = 100 // no calibration history exists, so this is never met
+	td := NewTimeDecay(cfg, newTestTimeDecayDB(t))
+
+	now := time.Now()
+	created := now.Add(-80 * 24 * time.Hour)
+	closes := now.Add(20 * 24 * time.Hour) // 80% elapsed
+
+	markets := []MarketData{
+		{
+			ID:          "decay-1",
+			OutcomeType: "BINARY",
+			Probability: 0.40,
+			Volume:      200,
+			Question:    "Will X happen by December 2026?",
+			CreatedTime: created,
+			CloseTime:   closes,
+		},
+	}
+
+	signals, err := td.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 signal, got %d", len(signals))
+	}
+
+	wantEstimated := 0.40 * (1 - 0.8*0.5)
+	wantEdge := 0.40 - wantEstimated
+	if diff := signals[0].Edge - wantEdge; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected linear-decay edge %.4f, got %.4f", wantEdge, signals[0].Edge)
+	}
+}
+
+func TestTimeDecay_UsesCalibratedCurveWhenAvailable(t *testing.T) {
+	database := newTestTimeDecayDB(t)
+	// Seed a calibration curve directly, as if a previous process had
+	// already fit and persisted it.
+	if _, err := database.Exec(`INSERT INTO decay_calibration (decile, sample_size, decay_factor) VALUES (8, 50, 0.5)`); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTimeDecayConfig()
+	cfg.MinCalibrationSamples = 10
+	td := NewTimeDecay(cfg, database)
+
+	now := time.Now()
+	created := now.Add(-80 * 24 * time.Hour)
+	closes := now.Add(20 * 24 * time.Hour) // 80% elapsed -> decile 8
+
+	markets := []MarketData{
+		{
+			ID:          "decay-1",
+			OutcomeType: "BINARY",
+			Probability: 0.40,
+			Volume:      200,
+			Question:    "Will X happen by December 2026?",
+			CreatedTime: created,
+			CloseTime:   closes,
+		},
+	}
+
+	signals, err := td.Evaluate(context.Background(), markets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 signal, got %d", len(signals))
+	}
+
+	wantEstimated := 0.40 * 0.5
+	wantEdge := 0.40 - wantEstimated
+	if diff := signals[0].Edge - wantEdge; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected calibrated-curve edge %.4f, got %.4f", wantEdge, signals[0].Edge)
+	}
+}
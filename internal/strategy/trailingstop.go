@@ -0,0 +1,322 @@
+package strategy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hyperkaehler/internal/config"
+)
+
+// TrailingStop operates on open positions rather than fresh opportunities:
+// for each unresolved bet it tracks the best probability move in its favor
+// since entry (persisted in position_peaks so a restart doesn't reset the
+// peak), and closes the position once a tiered trailing-stop is triggered.
+//
+// This lives in the strategy layer rather than a separate risk-layer
+// ExitManager: it already runs through the exact same Signal ->
+// risk.Manager.SizeSignals -> executor pipeline every entry strategy does,
+// and IsExit + the opposite Outcome is how a position gets flattened here —
+// there's no Manifold-style "sell", only an offsetting bet on the other
+// side, so a negative-amount or Action field would describe an operation
+// the executor can't actually perform. Splitting peak-tracking into a
+// parallel risk-layer PositionState map would duplicate position_peaks
+// without changing behavior.
+type TrailingStop struct {
+	cfg config.TrailingStopConfig
+	db  *sql.DB
+}
+
+func NewTrailingStop(cfg config.TrailingStopConfig, db *sql.DB) *TrailingStop {
+	return &TrailingStop{cfg: cfg, db: db}
+}
+
+func (ts *TrailingStop) Name() string  { return "trailingstop" }
+func (ts *TrailingStop) Enabled() bool { return ts.cfg.Enabled }
+
+func (ts *TrailingStop) Evaluate(_ context.Context, markets []MarketData) ([]Signal, error) {
+	if len(ts.cfg.TrailingActivationRatios) != len(ts.cfg.TrailingCallbackRates) {
+		return nil, fmt.Errorf("trailingstop: activation ratios and callback rates must be the same length")
+	}
+
+	marketsByID := make(map[string]MarketData, len(markets))
+	for _, m := range markets {
+		marketsByID[m.ID] = m
+	}
+
+	positions, err := ts.openPositions()
+	if err != nil {
+		return nil, fmt.Errorf("loading open positions: %w", err)
+	}
+
+	if err := ts.purgeSettledPeaks(positions); err != nil {
+		slog.Error("trailingstop: failed to purge settled peaks", "error", err)
+	}
+
+	var signals []Signal
+	for _, pos := range positions {
+		m, ok := marketsByID[pos.marketID]
+		if !ok || m.OutcomeType != "BINARY" || m.IsResolved {
+			continue
+		}
+
+		favorableMove := pos.entryProb - m.Probability
+		if pos.outcome == "YES" {
+			favorableMove = m.Probability - pos.entryProb
+		}
+
+		if sig, ok := ts.roiHardExit(pos, m); ok {
+			signals = append(signals, sig)
+			continue
+		}
+
+		if sig, ok := ts.timeExit(pos, m); ok {
+			signals = append(signals, sig)
+			continue
+		}
+
+		peakMove, err := ts.updatePeak(pos.marketID, pos.outcome, favorableMove)
+		if err != nil {
+			slog.Error("trailingstop: failed to update peak", "market", pos.marketID, "error", err)
+			continue
+		}
+
+		tier, activation, callback := ts.armedTier(peakMove)
+		if tier < 0 {
+			continue
+		}
+
+		retracement := peakMove - favorableMove
+		if retracement < callback*peakMove {
+			continue
+		}
+
+		exitOutcome := "NO"
+		if pos.outcome == "NO" {
+			exitOutcome = "YES"
+		}
+
+		slog.Info("trailingstop exit triggered",
+			"market", pos.marketID,
+			"entry_outcome", pos.outcome,
+			"tier", tier,
+			"activation", activation,
+			"peak_move", peakMove,
+			"retracement", retracement,
+		)
+
+		signals = append(signals, Signal{
+			MarketID:   pos.marketID,
+			Outcome:    exitOutcome,
+			Confidence: 1 - m.Probability,
+			MarketProb: m.Probability,
+			Edge:       retracement,
+			Strategy:   ts.Name(),
+			Reason: fmt.Sprintf("trailing stop tier %d: peak move %.3f retraced %.3f (callback %.2f)",
+				tier, peakMove, retracement, callback),
+			IsExit: true,
+		})
+	}
+
+	slog.Info("trailingstop evaluation complete", "positions", len(positions), "signals", len(signals))
+	return signals, nil
+}
+
+// roiHardExit checks pos against the configured hard take-profit/stop-loss
+// ROI thresholds, ahead of the trailing-stop tiers. ROI approximates the
+// mark-to-market return on the shares bought at entry: for a YES position,
+// (currentProb - entryProb) / entryProb; for NO, the mirrored ratio against
+// 1-entryProb.
+func (ts *TrailingStop) roiHardExit(pos openPosition, m MarketData) (Signal, bool) {
+	var roi float64
+	if pos.outcome == "YES" {
+		if pos.entryProb <= 0 {
+			return Signal{}, false
+		}
+		roi = (m.Probability - pos.entryProb) / pos.entryProb
+	} else {
+		if pos.entryProb >= 1 {
+			return Signal{}, false
+		}
+		roi = (pos.entryProb - m.Probability) / (1 - pos.entryProb)
+	}
+
+	var reason string
+	switch {
+	case ts.cfg.ROITakeProfitPct > 0 && roi >= ts.cfg.ROITakeProfitPct:
+		reason = fmt.Sprintf("roi take-profit hit: roi %.3f >= %.3f", roi, ts.cfg.ROITakeProfitPct)
+	case ts.cfg.ROIStopLossPct > 0 && roi <= -ts.cfg.ROIStopLossPct:
+		reason = fmt.Sprintf("roi stop-loss hit: roi %.3f <= -%.3f", roi, ts.cfg.ROIStopLossPct)
+	default:
+		return Signal{}, false
+	}
+
+	exitOutcome := "NO"
+	if pos.outcome == "NO" {
+		exitOutcome = "YES"
+	}
+
+	slog.Info("trailingstop roi exit triggered",
+		"market", pos.marketID,
+		"entry_outcome", pos.outcome,
+		"roi", roi,
+		"reason", reason,
+	)
+
+	return Signal{
+		MarketID:   pos.marketID,
+		Outcome:    exitOutcome,
+		Confidence: 1 - m.Probability,
+		MarketProb: m.Probability,
+		Edge:       roi,
+		Strategy:   ts.Name(),
+		Reason:     reason,
+		IsExit:     true,
+	}, true
+}
+
+// timeExit closes pos once m is within TimeExitHoursBeforeClose of its
+// CloseTime, regardless of ROI or trailing-tier state.
+func (ts *TrailingStop) timeExit(pos openPosition, m MarketData) (Signal, bool) {
+	if ts.cfg.TimeExitHoursBeforeClose <= 0 || m.CloseTime.IsZero() {
+		return Signal{}, false
+	}
+	remaining := time.Until(m.CloseTime)
+	if remaining > time.Duration(ts.cfg.TimeExitHoursBeforeClose*float64(time.Hour)) {
+		return Signal{}, false
+	}
+
+	exitOutcome := "NO"
+	if pos.outcome == "NO" {
+		exitOutcome = "YES"
+	}
+
+	reason := fmt.Sprintf("time exit: market closes in %s, within %.1fh threshold", remaining.Round(time.Minute), ts.cfg.TimeExitHoursBeforeClose)
+	slog.Info("trailingstop time exit triggered",
+		"market", pos.marketID,
+		"entry_outcome", pos.outcome,
+		"remaining", remaining,
+	)
+
+	return Signal{
+		MarketID:   pos.marketID,
+		Outcome:    exitOutcome,
+		Confidence: 1 - m.Probability,
+		MarketProb: m.Probability,
+		Edge:       0,
+		Strategy:   ts.Name(),
+		Reason:     reason,
+		IsExit:     true,
+	}, true
+}
+
+// armedTier returns the highest activation tier crossed by peakMove, along
+// with its activation/callback values, or -1 if no tier has armed yet.
+func (ts *TrailingStop) armedTier(peakMove float64) (tier int, activation, callback float64) {
+	tier = -1
+	for i, act := range ts.cfg.TrailingActivationRatios {
+		if peakMove >= act {
+			tier = i
+			activation = act
+			callback = ts.cfg.TrailingCallbackRates[i]
+		}
+	}
+	return tier, activation, callback
+}
+
+type openPosition struct {
+	marketID  string
+	outcome   string
+	entryProb float64
+}
+
+func (ts *TrailingStop) openPositions() ([]openPosition, error) {
+	rows, err := ts.db.Query(`
+		SELECT market_id, outcome, AVG(market_prob_at_bet)
+		FROM bot_bets
+		WHERE resolved = 0
+		GROUP BY market_id, outcome`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []openPosition
+	for rows.Next() {
+		var p openPosition
+		if err := rows.Scan(&p.marketID, &p.outcome, &p.entryProb); err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// purgeSettledPeaks deletes position_peaks rows for positions that have
+// since settled (or were closed by a prior exit signal), so a peak doesn't
+// linger and wrongly seed updatePeak if the same market/outcome is ever
+// re-entered. Without this, this table — the closest thing this strategy
+// has to the review-requested PositionState — would only ever grow.
+func (ts *TrailingStop) purgeSettledPeaks(open []openPosition) error {
+	stillOpen := make(map[string]bool, len(open))
+	for _, pos := range open {
+		stillOpen[pos.marketID+"\x00"+pos.outcome] = true
+	}
+
+	rows, err := ts.db.Query(`SELECT market_id, outcome FROM position_peaks`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var stale [][2]string
+	for rows.Next() {
+		var marketID, outcome string
+		if err := rows.Scan(&marketID, &outcome); err != nil {
+			return err
+		}
+		if !stillOpen[marketID+"\x00"+outcome] {
+			stale = append(stale, [2]string{marketID, outcome})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range stale {
+		if _, err := ts.db.Exec(`DELETE FROM position_peaks WHERE market_id = ? AND outcome = ?`, key[0], key[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *TrailingStop) updatePeak(marketID, outcome string, favorableMove float64) (float64, error) {
+	var peak float64
+	err := ts.db.QueryRow(`
+		SELECT peak_move FROM position_peaks WHERE market_id = ? AND outcome = ?`,
+		marketID, outcome,
+	).Scan(&peak)
+
+	if err == sql.ErrNoRows {
+		peak = favorableMove
+		_, err = ts.db.Exec(`
+			INSERT INTO position_peaks (market_id, outcome, peak_move) VALUES (?, ?, ?)`,
+			marketID, outcome, peak)
+		return peak, err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if favorableMove > peak {
+		peak = favorableMove
+		_, err = ts.db.Exec(`
+			UPDATE position_peaks SET peak_move = ?, updated_at = datetime('now')
+			WHERE market_id = ? AND outcome = ?`,
+			peak, marketID, outcome)
+	}
+	return peak, err
+}
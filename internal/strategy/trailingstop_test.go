@@ -0,0 +1,229 @@
+package strategy
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"hyperkaehler/internal/config"
+	"hyperkaehler/internal/db"
+)
+
+func newTrailingStopConfig() config.TrailingStopConfig {
+	return config.TrailingStopConfig{
+		Enabled:                  true,
+		TrailingActivationRatios: []float64{0.05, 0.10, 0.20},
+		TrailingCallbackRates:    []float64{0.50, 0.30, 0.15},
+	}
+}
+
+func newTrailingStopDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.Exec(`
+		INSERT INTO markets (id, question, outcome_type, mechanism, creator_id, created_time, close_time, url)
+		VALUES ('ts-1', 'Test?', 'BINARY', 'cpmm-1', 'user1', 0, 0, 'https://example.com')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(`
+		INSERT INTO bot_bets (market_id, strategy, outcome, amount, expected_prob, market_prob_at_bet, kelly_fraction)
+		VALUES ('ts-1', 'mispricing', 'YES', 10, 0.60, 0.40, 0.25)`); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func TestTrailingStop_WalksActivationTiersAndExits(t *testing.T) {
+	database := newTrailingStopDB(t)
+	ts := NewTrailingStop(newTrailingStopConfig(), database)
+
+	// Move 1: probability climbs from 0.40 to 0.46 (favorable move 0.06),
+	// crossing tier 0 (activation 0.05) but no retracement yet.
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.46},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Fatalf("expected no exit yet, got %d signals", len(signals))
+	}
+
+	// Move 2: probability runs to 0.65 (favorable move 0.25), crossing tier 2
+	// (activation 0.20, callback 0.15). Still no retracement.
+	signals, err = ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.65},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Fatalf("expected no exit at new peak, got %d signals", len(signals))
+	}
+
+	// Move 3: probability retraces to 0.58. Peak move was 0.25; current move
+	// is 0.18; retracement 0.07 >= 0.15*0.25=0.0375, tier 2 fires.
+	signals, err = ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.58},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 exit signal, got %d", len(signals))
+	}
+	if !signals[0].IsExit {
+		t.Error("expected IsExit to be true")
+	}
+	if signals[0].Outcome != "NO" {
+		t.Errorf("expected NO exit for a YES position, got %s", signals[0].Outcome)
+	}
+}
+
+func TestTrailingStop_ROITakeProfitExitsImmediately(t *testing.T) {
+	database := newTrailingStopDB(t)
+	cfg := newTrailingStopConfig()
+	cfg.ROITakeProfitPct = 0.40
+	ts := NewTrailingStop(cfg, database)
+
+	// Entry prob 0.40, current prob 0.58: ROI = (0.58-0.40)/0.40 = 0.45,
+	// above the 0.40 take-profit threshold, fires on the very first
+	// evaluation with no trailing tier needed.
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.58},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 take-profit exit signal, got %d", len(signals))
+	}
+	if !signals[0].IsExit || signals[0].Outcome != "NO" {
+		t.Errorf("expected a NO exit signal, got %+v", signals[0])
+	}
+}
+
+func TestTrailingStop_ROIStopLossExitsImmediately(t *testing.T) {
+	database := newTrailingStopDB(t)
+	cfg := newTrailingStopConfig()
+	cfg.ROIStopLossPct = 0.30
+	ts := NewTrailingStop(cfg, database)
+
+	// Entry prob 0.40, current prob 0.25: ROI = (0.25-0.40)/0.40 = -0.375,
+	// below the -0.30 stop-loss threshold.
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.25},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 stop-loss exit signal, got %d", len(signals))
+	}
+	if !signals[0].IsExit || signals[0].Outcome != "NO" {
+		t.Errorf("expected a NO exit signal, got %+v", signals[0])
+	}
+}
+
+func TestTrailingStop_TimeExitNearClose(t *testing.T) {
+	database := newTrailingStopDB(t)
+	cfg := newTrailingStopConfig()
+	cfg.TimeExitHoursBeforeClose = 2
+	ts := NewTrailingStop(cfg, database)
+
+	// Market closes in 1 hour, under the 2h threshold, and ROI/trailing
+	// tiers haven't armed yet — the time exit should still fire.
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.42, CloseTime: time.Now().Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 {
+		t.Fatalf("expected 1 time exit signal, got %d", len(signals))
+	}
+	if !signals[0].IsExit || signals[0].Outcome != "NO" {
+		t.Errorf("expected a NO exit signal, got %+v", signals[0])
+	}
+}
+
+func TestTrailingStop_NoTimeExitWellBeforeClose(t *testing.T) {
+	database := newTrailingStopDB(t)
+	cfg := newTrailingStopConfig()
+	cfg.TimeExitHoursBeforeClose = 2
+	ts := NewTrailingStop(cfg, database)
+
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.42, CloseTime: time.Now().Add(48 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected no time exit with close far away, got %d", len(signals))
+	}
+}
+
+func TestTrailingStop_NoExitBeforeActivation(t *testing.T) {
+	database := newTrailingStopDB(t)
+	ts := NewTrailingStop(newTrailingStopConfig(), database)
+
+	// Favorable move of only 0.02, below the lowest activation tier (0.05).
+	signals, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.42},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 0 {
+		t.Errorf("expected no signals below activation, got %d", len(signals))
+	}
+}
+
+func TestTrailingStop_PurgesPeakForSettledPosition(t *testing.T) {
+	database := newTrailingStopDB(t)
+	ts := NewTrailingStop(newTrailingStopConfig(), database)
+
+	// First cycle establishes a peak for ts-1/YES.
+	if _, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.46},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var peaks int
+	if err := database.QueryRow(`SELECT COUNT(*) FROM position_peaks WHERE market_id = 'ts-1' AND outcome = 'YES'`).Scan(&peaks); err != nil {
+		t.Fatal(err)
+	}
+	if peaks != 1 {
+		t.Fatalf("expected a peak row after the first cycle, got %d", peaks)
+	}
+
+	// Position settles outside of TrailingStop (resolution, or another exit
+	// signal getting executed) — it no longer shows up as open.
+	if _, err := database.Exec(`UPDATE bot_bets SET resolved = 1, pnl = 5, resolved_at = datetime('now') WHERE market_id = 'ts-1'`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ts.Evaluate(context.Background(), []MarketData{
+		{ID: "ts-1", OutcomeType: "BINARY", Probability: 0.48},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.QueryRow(`SELECT COUNT(*) FROM position_peaks WHERE market_id = 'ts-1' AND outcome = 'YES'`).Scan(&peaks); err != nil {
+		t.Fatal(err)
+	}
+	if peaks != 0 {
+		t.Errorf("expected the settled position's peak to be purged, got %d rows", peaks)
+	}
+}